@@ -0,0 +1,40 @@
+package main
+
+// Carries a balance through the statement: the opening balance from -opening-balance,
+// plus the credits and minus the debits in range, gives the closing balance.
+// Every line in range counts, including the ones -ref and -weekdays-only leave out of the fees,
+// since those still moved money in and out of the account.
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The balance before the first line in range, set from -opening-balance
+var openingBalance float64
+
+// Adds a line in range to the debits and credits
+// A negative amount is a credit on statements with a single amount column; ones with a credit column have it there instead.
+func addFlow(res *Result, row []string, colAmnt int, colCredit int) {
+	if amount, _, err := parseAmount(row[colAmnt]); err == nil {
+		if amount < 0 {
+			res.Credits -= amount
+		} else {
+			res.Debits += amount
+		}
+	}
+	if colCredit >= 0 && colCredit < len(row) && strings.TrimSpace(row[colCredit]) != "" {
+		if credit, _, err := parseAmount(row[colCredit]); err == nil {
+			res.Credits += credit
+		}
+	}
+}
+
+// Writes the opening balance, the movements in range and the closing balance they lead to
+func writeBalances(w io.Writer, res Result) {
+	closing := openingBalance + res.Credits - res.Debits
+	fmt.Fprintln(w, "Opening balance:", formatAmount(openingBalance))
+	fmt.Fprintln(w, "Debits:", formatAmount(res.Debits)+", Credits:", formatAmount(res.Credits))
+	fmt.Fprintln(w, "Closing balance:", formatAmount(closing))
+}
@@ -0,0 +1,18 @@
+package main
+
+// -bank-format lays the summary out like the bank's monthly fee statement, so the two can be checked side by side
+// The layout is an ordinary -template; when the bank changes its statement, change bankTemplate to match.
+
+import "text/template"
+
+// Date, label and amount for each fee in the bank's column order, then the total line
+const bankTemplate = `RELEVÉ DES FRAIS ET COMMISSIONS
+Période du {{.Start}} au {{.End}}
+Date        Libellé                                        Montant
+{{range .Fees}}{{printf "%-10s  %-40s  %12s" (.Date.Format "02/01/2006") .Desc .Amount}}
+{{end}}{{printf "%-52s  %12s" "TOTAL DES FRAIS" .Total}}
+`
+
+func parseBankTemplate() *template.Template {
+	return template.Must(template.New("bank").Parse(bankTemplate))
+}
@@ -0,0 +1,62 @@
+package main
+
+// Fee spike monitoring with -baseline: the fee total is compared with a baseline file, such as the same
+// account's export for an earlier period, and with -alert-pct a rise of more than that percentage fails the run
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Compares total with the baseline file's fees over all of its dates, and sets the exit status if it's an alert
+func checkBaseline(total float64) {
+	if *baselineFlag == "" {
+		return
+	}
+	var w io.Writer = os.Stdout
+	if *quietFlag {
+		w = os.Stderr
+	}
+
+	header, data, err := readFile(*baselineFlag)
+	if err != nil {
+		fail("Could not read the -baseline file:", err)
+		return
+	}
+	date1, date2, err := fileDateSpan(header, data)
+	if err != nil {
+		fail("Could not read the -baseline file:", err)
+		return
+	}
+	base, err := calculate(header, data, date1, date2, false)
+	if err != nil {
+		fail("Could not calculate the -baseline file:", err)
+		return
+	}
+
+	fmt.Fprintln(w, "BASELINE:", formatAmount(base.Total), "in", filepath.Base(*baselineFlag), "("+date1.Format(dateEntry), "to", date2.Format(dateEntry)+")")
+	if isZero(base.Total) || base.Total < 0 {
+		fmt.Fprintln(w, "The baseline has no fees to compare with.")
+		if *alertPctFlag > 0 && total > 0 && !isZero(total) {
+			fail("ALERT: there are fees of", formatAmount(total), "where the baseline had none.")
+		}
+		return
+	}
+
+	change := (total - base.Total) / base.Total * 100
+	sign := ""
+	if change > 0 {
+		sign = "+"
+	}
+	fmt.Fprintln(w, "CHANGE:", sign+formatPercent(change)+"%")
+	if *alertPctFlag <= 0 {
+		return
+	}
+	if change > *alertPctFlag {
+		fail("ALERT: fees are up " + formatPercent(change) + "% on the baseline, more than the " + formatPercent(*alertPctFlag) + "% allowed.")
+	} else {
+		fmt.Fprintln(w, "OK: fees are not up by more than "+formatPercent(*alertPctFlag)+"% on the baseline.")
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+// Text charts for the terminal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Checks the -chart-width, since the bars are padded out to it
+func checkChartWidth(width int) error {
+	if width < 1 {
+		return fmt.Errorf("The -chart-width must be at least 1, not %d.", width)
+	}
+	return nil
+}
+
+// Draws one bar per month, scaled so the largest month fills width characters
+func writeChart(w io.Writer, byMonth map[string]float64, width int) {
+	months := sortedKeys(byMonth)
+	if len(months) == 0 {
+		return
+	}
+
+	var largest float64 = 0
+	for _, month := range months {
+		if byMonth[month] > largest {
+			largest = byMonth[month]
+		}
+	}
+
+	fmt.Fprintln(w, "Fees by month:")
+	for _, month := range months {
+		bar := 0
+		if largest > 0 && byMonth[month] > 0 && !isZero(largest) {
+			bar = int(byMonth[month] / largest * float64(width))
+		}
+		fmt.Fprintf(w, "%s %s %s\n", month, strings.Repeat("█", bar)+strings.Repeat(" ", width-bar), formatAmount(byMonth[month]))
+	}
+	fmt.Fprintln(w)
+}
+
+// Draws each fee word's share of the total as a line, where width characters would be the whole total, largest share first
+// A word whose reversals outweigh its fees has no share, so it gets an empty line
+func writePie(w io.Writer, byKeyword map[string]float64, total float64, width int) {
+	if len(byKeyword) == 0 || total <= 0 || isZero(total) {
+		return
+	}
+
+	keywords := sortedKeys(byKeyword)
+	sort.SliceStable(keywords, func(i, j int) bool { return byKeyword[keywords[i]] > byKeyword[keywords[j]] })
+	longest := 0
+	for _, keyword := range keywords {
+		if len([]rune(keyword)) > longest {
+			longest = len([]rune(keyword))
+		}
+	}
+
+	fmt.Fprintln(w, "Share of fees by keyword:")
+	for _, keyword := range keywords {
+		share := byKeyword[keyword] / total
+		bar := 0
+		if share > 0 {
+			bar = int(share*float64(width) + 0.5)
+		}
+		if bar > width {
+			bar = width
+		}
+		padding := strings.Repeat(" ", longest-len([]rune(keyword)))
+		fmt.Fprintf(w, "%s%s %s %5s%%\n", keyword, padding, strings.Repeat("|", bar)+strings.Repeat(" ", width-bar), formatPercent(share*100))
+	}
+	fmt.Fprintln(w)
+}
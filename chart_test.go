@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChartWidth(t *testing.T) {
+	for _, width := range []int{-5, 0} {
+		if err := checkChartWidth(width); err == nil {
+			t.Errorf("checkChartWidth(%d) accepted a width the bars can't be padded to", width)
+		}
+	}
+	if err := checkChartWidth(1); err != nil {
+		t.Errorf("checkChartWidth(1) = %v", err)
+	}
+}
+
+func TestWritePieNarrow(t *testing.T) {
+	var out strings.Builder
+	writePie(&out, map[string]float64{"frais": 30, "taxes": 10, "timbre": -5}, 35, 1)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[1], "frais  | ") {
+		t.Errorf("writePie at width 1 wrote %q", out.String())
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+// Copying the total to the clipboard with -clipboard, so after a drag-and-drop it can be pasted straight into
+// an email or a spreadsheet. writeClipboard is in the clipboard_*.go file for each platform.
+
+import "fmt"
+
+// Copies the fee total to the clipboard if -clipboard is set
+func copyTotal(total float64) {
+	if !*clipboardFlag {
+		return
+	}
+	if isZero(total) {
+		total = 0
+	}
+	if err := writeClipboard(formatAmount(total)); err != nil {
+		fail("Could not copy the total to the clipboard:", err)
+		return
+	}
+	if !*quietFlag {
+		fmt.Println("Copied the total to the clipboard.")
+	}
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// Copies text to the clipboard with pbcopy, which comes with macOS
+func writeClipboard(text string) error {
+	cmd := exec.Command("pbcopy")
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
@@ -0,0 +1,10 @@
+//go:build !windows && !darwin
+
+package main
+
+import "errors"
+
+// There's no clipboard tool that every other system is sure to have, so copying isn't supported there
+func writeClipboard(text string) error {
+	return errors.New("the clipboard is only supported on Windows and macOS.")
+}
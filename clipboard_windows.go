@@ -0,0 +1,13 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// Copies text to the clipboard with clip.exe, which comes with Windows
+func writeClipboard(text string) error {
+	cmd := exec.Command("clip")
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
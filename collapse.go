@@ -0,0 +1,45 @@
+package main
+
+// Merging repeated fees in listings with -collapse, e.g. "3× frais SMS @ 10.00" instead of three lines
+// Only the listing changes; totals are always worked out from every fee.
+
+// One line of a collapsed listing: the same fee charged Count times, from First to Last
+type collapsedFee struct {
+	First Transaction
+	Last  Transaction
+	Count int
+}
+
+// Amount of all the fees in the line together
+func (fee collapsedFee) Total() float64 {
+	return fee.First.Amount * float64(fee.Count)
+}
+
+// Checks whether two fees are the same charge: same description and amount
+func sameFee(a Transaction, b Transaction) bool {
+	return a.Desc == b.Desc && nearlyEqual(a.Amount, b.Amount)
+}
+
+// Merges the fees in a row that are the same charge. With all, every fee is merged into the first one like it,
+// wherever it is, and the lines stay in the order each fee first appears
+func collapseFees(transactions []Transaction, all bool) []collapsedFee {
+	var fees []collapsedFee
+	for _, trx := range transactions {
+		merged := false
+		for i := len(fees) - 1; i >= 0; i-- {
+			if sameFee(fees[i].First, trx) {
+				fees[i].Last = trx
+				fees[i].Count += 1
+				merged = true
+				break
+			}
+			if !all {
+				break
+			}
+		}
+		if !merged {
+			fees = append(fees, collapsedFee{First: trx, Last: trx, Count: 1})
+		}
+	}
+	return fees
+}
@@ -0,0 +1,58 @@
+package main
+
+// Degraded exports that merge the description and the amount into one cell, like "FRAIS SMS 10.00", with -combined-col.
+// The cell is split before calculating, so the rest of the pipeline sees the usual separate columns.
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// A number at the end of a cell, after a space, e.g. the "10.00" in "FRAIS SMS 10.00"
+// It's written the same way as in an amount column, so it has no thousands separators
+var trailingAmount = regexp.MustCompile(`^(.*?)\s+(-?\d+(?:\.\d+)?)$`)
+
+// Splits the trailing number off a combined cell. A cell without one is all description, with a blank amount.
+func splitTrailingAmount(cell string) (desc string, amount string) {
+	cell = strings.TrimSpace(cell)
+	match := trailingAmount.FindStringSubmatch(cell)
+	if match == nil {
+		return cell, ""
+	}
+	return match[1], match[2]
+}
+
+// Returns a copy of the header and rows with the combined column turned into the description column (named by -desc-col)
+// and the amount split off into the amount column (named by -amnt-col). Exports often keep an empty amount column,
+// so the amount goes there if the file has one, and into a new last column if not; a cell with no number on the end
+// leaves any amount already in the row alone. The rows passed in aren't changed, since they're kept for recalculating with other dates.
+func splitCombinedColumn(header []string, data [][]string, column string) ([]string, [][]string, error) {
+	col := getindex(header, column)
+	if col < 0 {
+		return nil, nil, errors.New("The combined column \"" + column + "\" was not found in the file.")
+	}
+
+	newHeader := append([]string(nil), header...)
+	newHeader[col] = *descColFlag
+	amntCol := getindex(header, *amntColFlag)
+	if amntCol < 0 || amntCol == col {
+		newHeader = append(newHeader, *amntColFlag)
+		amntCol = len(newHeader) - 1
+	}
+
+	newData := make([][]string, len(data))
+	for i, row := range data {
+		newRow := make([]string, len(newHeader))
+		copy(newRow, row)
+		if col < len(row) {
+			desc, amount := splitTrailingAmount(row[col])
+			newRow[col] = desc
+			if amount != "" {
+				newRow[amntCol] = amount
+			}
+		}
+		newData[i] = newRow
+	}
+	return newHeader, newData, nil
+}
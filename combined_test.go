@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestCombinedColumnEmptyAmountColumn(t *testing.T) {
+	defer func(saved string) { *combinedColFlag = saved }(*combinedColFlag)
+	*combinedColFlag = "Description"
+
+	header, data := testFile("Date Trx,Description,Debit",
+		"03-Jul-23,frais SMS 10.00,",
+		"04-Jul-23,taxes 3.25,",
+	)
+	res, err := calculate(header, data, testDate(t, "2023-07-01"), testDate(t, "2023-07-31"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Skipped != 0 || !isZero(res.Total-13.25) {
+		t.Errorf("Total = %v with %d skipped; want 13.25 from the amounts split into the empty Debit column", res.Total, res.Skipped)
+	}
+	if len(res.Transactions) > 0 && res.Transactions[0].Desc != "frais SMS" {
+		t.Errorf("first description = %q; want \"frais SMS\"", res.Transactions[0].Desc)
+	}
+}
+
+func TestCombinedColumnNoAmountColumn(t *testing.T) {
+	header, data := testFile("Date Trx,Description", "03-Jul-23,frais SMS 10.00")
+	newHeader, newData, err := splitCombinedColumn(header, data, "Description")
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := getindex(newHeader, *amntColFlag)
+	if col != 2 || newData[1][col] != "10.00" || newData[1][1] != "frais SMS" {
+		t.Errorf("header %q, row %q; want the amount in a new last column", newHeader, newData[1])
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+// Optional settings file, read from the same folder as the program unless -config points elsewhere
+// It is plain JSON so it can be edited in Notepad, e.g.
+//
+//	{
+//	  "reports": [
+//	    {"name": "ATM", "keywords": ["RETRAIT ATM", "GAB"]},
+//	    {"name": "Transfers", "column": "Description", "keywords": ["VIREMENT"]}
+//	  ]
+//	}
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const configFile = "config.json"
+
+type Config struct {
+	Reports []ReportProfile `json:"reports"` //Named totals computed alongside the fee total
+}
+
+// A named filter: rows in range whose column contains any of the keywords are totaled under the name
+type ReportProfile struct {
+	Name     string   `json:"name"`
+	Column   string   `json:"column"` //Header of the column to search; defaults to the description column
+	Keywords []string `json:"keywords"`
+}
+
+var config Config
+
+// Reads the config file. A missing file is only an error if it was asked for by name with -config.
+func loadConfig(path string) (Config, error) {
+	var cfg Config
+	explicit := path != ""
+	if !explicit {
+		path = programFile(configFile)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+	err = json.Unmarshal(content, &cfg)
+	return cfg, err
+}
+
+// Returns the path of a file in the same folder as the program
+func programFile(name string) string {
+	exe, err := os.Executable()
+	if err != nil {
+		return name
+	}
+	return filepath.Join(filepath.Dir(exe), name)
+}
@@ -0,0 +1,41 @@
+package main
+
+// A one-row summary for spreadsheets with -format csv-summary: a header line and one line for the run, e.g.
+//
+//	file,start,end,total,count,lines
+//	x.csv,2023-07-01,2023-07-31,1234.56,42,2000
+//
+// In the multi-file mode the row is for all the files together.
+// Currencies are never added together and there's only the one total, so fees in more than one currency are refused, as with -bare.
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type csvSummaryOutput struct {
+	w    io.Writer
+	info outputInfo
+}
+
+func (o csvSummaryOutput) WriteTransaction(trx Transaction) {}
+
+func (o csvSummaryOutput) WriteSummary(res Result) {
+	if len(res.ByCurrency) > 1 {
+		fail("-csv-summary can only write one total, but the fees are in more than one currency:", strings.Join(sortedKeys(res.ByCurrency), ", "))
+		return
+	}
+	writer := csv.NewWriter(o.w)
+	writer.Write([]string{"file", "start", "end", "total", "count", "lines"})
+	writer.Write([]string{
+		o.info.Name,
+		o.info.Start.Format(dateEntry),
+		o.info.End.Format(dateEntry),
+		machineAmount(res.Total),
+		strconv.Itoa(len(res.Transactions)),
+		strconv.Itoa(res.Lines),
+	})
+	writer.Flush()
+}
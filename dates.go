@@ -0,0 +1,152 @@
+package main
+
+// Working out which date layout a file uses, so exports with a different date format still parse
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layouts tried when the file's dates don't match dateFormat. Add new ones here as needed
+var dateCandidates = []string{
+	dateFormat,
+	"02-Jan-2006",
+	"02/01/2006",
+	"01/02/2006",
+	"02-01-2006",
+	"02.01.2006",
+	"02/01/06",
+	"2006-01-02",
+	"2006/01/02",
+	"Jan 02, 2006",
+	unixLayout,
+}
+
+// Stands in for a layout when the dates are Unix timestamps (seconds since 1970), as some API exports have them
+const unixLayout = "unix"
+
+// Number of date cells looked at when working out the layout
+const dateSamples = 20
+
+// Picks the date layout for a file from the first few date cells
+// Cells that aren't a date in any layout are bad rows, which are dealt with when they're reached, so they aren't used.
+// The configured dateFormat is kept if it fits; otherwise the layout is detected from the candidates.
+func fileDateFormat(data [][]string, colDate int) (string, error) {
+	var samples []string
+	for _, row := range data {
+		if len(samples) == dateSamples {
+			break
+		}
+		if colDate < len(row) && strings.TrimSpace(row[colDate]) != "" && parsesAny(row[colDate]) {
+			samples = append(samples, row[colDate])
+		}
+	}
+	//If nothing parses, keep a sample so the error can show what the dates look like
+	if len(samples) == 0 {
+		for _, row := range data {
+			if colDate < len(row) && strings.TrimSpace(row[colDate]) != "" {
+				samples = append(samples, row[colDate])
+				break
+			}
+		}
+	}
+
+	if parsesAll(dateFormat, samples) {
+		return dateFormat, nil
+	}
+	return detectDateFormat(samples)
+}
+
+// Returns the one candidate layout that parses every sample
+// It is an error if none of them do, or if more than one does (e.g. 02/01/2006 vs 01/02/2006 when no day is over 12)
+func detectDateFormat(samples []string) (string, error) {
+	if len(samples) == 0 {
+		return "", errors.New("There are no dates to work out the date format from.")
+	}
+
+	var fits []string
+	for _, layout := range dateCandidates {
+		if parsesAll(layout, samples) {
+			fits = append(fits, layout)
+		}
+	}
+
+	switch len(fits) {
+	case 0:
+		return "", errors.New("The dates in the file (e.g. \"" + samples[0] + "\") are not in a recognised format.")
+	case 1:
+		return fits[0], nil
+	default:
+		return "", errors.New("The date format is ambiguous; the dates fit " + strings.Join(fits, " and ") + ". Set dateFormat to the right one.")
+	}
+}
+
+// Checks that every sample parses with the layout
+func parsesAll(layout string, samples []string) bool {
+	if len(samples) == 0 {
+		return false
+	}
+	for _, sample := range samples {
+		if _, err := parseDate(layout, sample); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks whether the cell is a date in any of the candidate layouts
+func parsesAny(cell string) bool {
+	for _, layout := range dateCandidates {
+		if _, err := parseDate(layout, cell); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Parses a date cell with the layout, in the -tz time zone
+func parseDate(layout string, cell string) (time.Time, error) {
+	cell = strings.TrimSpace(cell)
+	if layout == unixLayout {
+		return parseUnixDate(cell)
+	}
+	date, err := time.ParseInLocation(layout, cell, location)
+	if err != nil || *yearPivotFlag == 0 || !hasTwoDigitYear(layout) {
+		return date, err
+	}
+	return pivotYear(date, *yearPivotFlag), nil
+}
+
+// Checks whether a layout has a two-digit year ("06") rather than a four-digit one ("2006")
+func hasTwoDigitYear(layout string) bool {
+	return strings.Contains(strings.ReplaceAll(layout, "2006", ""), "06")
+}
+
+// Puts a date parsed from a two-digit year into the century given by -year-pivot:
+// years below the pivot are 20xx and the rest are 19xx, so with a pivot of 30, "29" is 2029 and "30" is 1930.
+// Go's own rule is the same with a pivot of 69.
+func pivotYear(date time.Time, pivot int) time.Time {
+	year := date.Year() % 100
+	if year < pivot {
+		year += 2000
+	} else {
+		year += 1900
+	}
+	return time.Date(year, date.Month(), date.Day(), date.Hour(), date.Minute(), date.Second(), date.Nanosecond(), date.Location())
+}
+
+// Parses a Unix timestamp into the day it falls on in the -tz time zone, since the range is compared by day.
+// Only 10 digits are accepted, which covers 2001 to 2286, so that short numbers like "20230714" aren't mistaken for one.
+func parseUnixDate(cell string) (time.Time, error) {
+	if len(cell) != 10 || strings.Trim(cell, "0123456789") != "" {
+		return time.Time{}, errors.New("\"" + cell + "\" is not a Unix timestamp.")
+	}
+	seconds, err := strconv.ParseInt(cell, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	moment := time.Unix(seconds, 0).In(location)
+	return time.Date(moment.Year(), moment.Month(), moment.Day(), 0, 0, 0, 0, location), nil
+}
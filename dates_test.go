@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnixDates(t *testing.T) {
+	saved := location
+	location = time.UTC
+	defer func() { location = saved }()
+
+	header, data := testFile("Date Trx,Description,Debit,Credit",
+		"1688385600,frais SMS,10.00,", //2023-07-03 12:00 UTC
+		"1689336000,taxes,3.25,",      //2023-07-14 12:00 UTC
+		"1691064000,frais SMS,5.00,",  //2023-08-03 12:00 UTC
+	)
+	res, err := calculate(header, data, testDate(t, "2023-07-01"), testDate(t, "2023-07-31"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isZero(res.Total-13.25) || len(res.Transactions) != 2 {
+		t.Errorf("Total = %v from %d fees; want 13.25 from 2", res.Total, len(res.Transactions))
+	}
+	if len(res.Transactions) > 0 && res.Transactions[0].Date.Format(dateEntry) != "2023-07-03" {
+		t.Errorf("first fee dated %s; want 2023-07-03", res.Transactions[0].Date.Format(dateEntry))
+	}
+
+	if _, err := parseUnixDate("20230714"); err == nil {
+		t.Error("parseUnixDate took the 8 digit date 20230714 as a timestamp")
+	}
+}
+
+func TestYearPivot(t *testing.T) {
+	defer func(saved int) { *yearPivotFlag = saved }(*yearPivotFlag)
+
+	tests := []struct {
+		pivot  int
+		layout string
+		cell   string
+		want   string
+	}{
+		{30, "02-Jan-06", "03-Jul-29", "2029-07-03"},
+		{30, "02-Jan-06", "03-Jul-30", "1930-07-03"},
+		{30, "02-Jan-06", "03-Jul-00", "2000-07-03"},
+		{0, "02-Jan-06", "03-Jul-68", "2068-07-03"}, //Go's own rule
+		{0, "02-Jan-06", "03-Jul-69", "1969-07-03"},
+		{100, "02-Jan-06", "03-Jul-99", "2099-07-03"},
+		{100, "02-Jan-06", "03-Jul-75", "2075-07-03"},
+		{30, "2006-01-02", "1975-07-03", "1975-07-03"}, //Four-digit years are left alone
+		{30, "02/01/2006", "03/07/2045", "2045-07-03"},
+	}
+	for _, test := range tests {
+		*yearPivotFlag = test.pivot
+		date, err := parseDate(test.layout, test.cell)
+		if err != nil {
+			t.Errorf("parseDate(%q, %q) failed: %v", test.layout, test.cell, err)
+			continue
+		}
+		if got := date.Format(dateEntry); got != test.want {
+			t.Errorf("pivot %d: parseDate(%q, %q) = %s; want %s", test.pivot, test.layout, test.cell, got, test.want)
+		}
+	}
+
+	if hasTwoDigitYear("2006-01-02") || !hasTwoDigitYear("02-Jan-06") {
+		t.Error("hasTwoDigitYear mixed up two and four-digit year layouts")
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+// Dropping fees that turn up in more than one file with -dedup, for exports whose date ranges overlap
+// The same fee can legitimately be charged twice on one day, so repeats inside a single file are always kept;
+// only as many copies as an earlier file already had are treated as duplicates.
+// Only the fees are kept as transactions, so they are all that can be deduplicated: interest, non-fee debits,
+// the balance and report totals from overlapping lines still count once for each file.
+
+import (
+	"math"
+	"strconv"
+)
+
+// Identifies a fee by its date, description and amount. The amount is rounded to the -epsilon precision,
+// so "10.00", "10.0" and "10" all give the same key
+func dedupKey(trx Transaction) string {
+	steps := int64(math.Round(trx.Amount / (2 * *epsilonFlag)))
+	return trx.Date.Format(dateEntry) + "\x00" + trx.Desc + "\x00" + strconv.FormatInt(steps, 10)
+}
+
+// Removes the fees in each file that an earlier file already had, adjusting that file's totals to match
+func dedupFiles(results []Result) {
+	seen := make(map[string]int) //Most copies of each fee in any one file so far
+	for i := range results {
+		res := &results[i]
+		if res.Err != nil {
+			continue
+		}
+		copies := make(map[string]int)
+		var kept []Transaction
+		for _, trx := range res.Transactions {
+			key := dedupKey(trx)
+			copies[key] += 1
+			if copies[key] <= seen[key] {
+				res.removeFee(trx)
+				res.Duplicates += 1
+				continue
+			}
+			kept = append(kept, trx)
+		}
+		res.Transactions = kept
+		for key, count := range copies {
+			if count > seen[key] {
+				seen[key] = count
+			}
+		}
+	}
+}
+
+// Takes a fee back out of the result's totals and subtotals
+func (res *Result) removeFee(trx Transaction) {
+	res.Total -= trx.Amount
+	res.ByKeyword[trx.Keyword] -= trx.Amount
+	res.ByKeywordCount[trx.Keyword] -= 1
+	//A word or day with no fees left shouldn't show up as a zero line in the breakdowns
+	if res.ByKeywordCount[trx.Keyword] <= 0 {
+		delete(res.ByKeyword, trx.Keyword)
+		delete(res.ByKeywordCount, trx.Keyword)
+	}
+	res.ByMonth[trx.Date.Format("2006-01")] -= trx.Amount
+	addMonthKeyword(res.ByMonthKeyword, trx.Date.Format("2006-01"), trx.Keyword, -trx.Amount)
+	day := trx.Date.Format(dateEntry)
+	res.ByDay[day] = res.ByDay[day].add(-trx.Amount, -1)
+	if res.ByDay[day].Count <= 0 {
+		delete(res.ByDay, day)
+	}
+	res.ByWeekday[trx.Date.Weekday()] = res.ByWeekday[trx.Date.Weekday()].add(-trx.Amount, -1)
+	if _, ok := res.ByCurrency[trx.Currency]; ok {
+		res.ByCurrency[trx.Currency] -= trx.Amount
+	}
+	if *counterpartyFlag {
+		party := counterparty(trx.Desc)
+		res.ByCounterparty[party] = res.ByCounterparty[party].add(-trx.Amount, -1)
+		if res.ByCounterparty[party].Count <= 0 {
+			delete(res.ByCounterparty, party)
+		}
+	}
+	if trx.Reversal {
+		res.Reversals -= 1
+	}
+}
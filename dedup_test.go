@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+func TestDedupFilesEqualAmounts(t *testing.T) {
+	date1, date2 := testDate(t, "2023-07-01"), testDate(t, "2023-07-31")
+	var results []Result
+	for _, rows := range [][]string{
+		{"03-Jul-23,frais SMS,10.00,"},
+		{"03-Jul-23,frais SMS,10,", "03-Jul-23,frais SMS,10.0,", "04-Jul-23,frais SMS,10,"},
+	} {
+		header, data := testFile("Date Trx,Description,Debit,Credit", rows...)
+		res, err := calculate(header, data, date1, date2, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, res)
+	}
+
+	dedupFiles(results)
+
+	//The second file's own repeat is a second charge, so only one of its two copies was already in the first file
+	if results[0].Duplicates != 0 || len(results[0].Transactions) != 1 {
+		t.Errorf("first file: %d duplicates, %d fees; want 0 and 1", results[0].Duplicates, len(results[0].Transactions))
+	}
+	if results[1].Duplicates != 1 || len(results[1].Transactions) != 2 {
+		t.Errorf("second file: %d duplicates, %d fees; want 1 and 2", results[1].Duplicates, len(results[1].Transactions))
+	}
+	if !isZero(results[1].Total - 20) {
+		t.Errorf("second file total = %v; want 20.00 after taking out the duplicate", results[1].Total)
+	}
+}
+
+func TestDedupFilesInterestOverlap(t *testing.T) {
+	date1, date2 := testDate(t, "2023-07-01"), testDate(t, "2023-07-31")
+	var results []Result
+	for _, rows := range [][]string{
+		{"03-Jul-23,frais SMS,10.00,", "05-Jul-23,intérêts débiteurs,5.00,"},
+		{"03-Jul-23,frais SMS,10.00,", "05-Jul-23,intérêts débiteurs,5.00,"},
+	} {
+		header, data := testFile("Date Trx,Description,Debit,Credit", rows...)
+		res, err := calculate(header, data, date1, date2, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, res)
+	}
+
+	dedupFiles(results)
+	combined, _ := combineResults(results)
+
+	if !isZero(combined.Total-10) || combined.Duplicates != 1 {
+		t.Errorf("Total = %v with %d duplicates; want 10.00 with the second fee left out", combined.Total, combined.Duplicates)
+	}
+	//The keyword had all its fees in the second file taken out, so it's gone from that file's breakdown
+	if _, ok := results[1].ByKeyword["frais"]; ok {
+		t.Errorf("ByKeyword = %v; want no zero entry for frais", results[1].ByKeyword)
+	}
+	//-dedup only covers fees, so the interest line still counts once for each file
+	if !isZero(combined.Interest-10) || combined.InterestCount != 2 {
+		t.Errorf("Interest = %v from %d; want 10.00 from 2, since interest isn't deduplicated", combined.Interest, combined.InterestCount)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+// Shows what a change of settings did, by comparing the fees matched on one pass over a file with the next:
+// after each add or remove with -interactive, and on each continue once the word lists have been re-read
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Fees matched by the last pass over the current file, or nil before the first pass
+var previousPass []Transaction
+
+// Writes "+N newly matched, -M no longer matched" and then those fees, keyed by line since the rows don't change between passes
+func writeMatchDiff(w io.Writer, previous []Transaction, current []Transaction) {
+	before := make(map[int]Transaction, len(previous))
+	for _, trx := range previous {
+		before[trx.Line] = trx
+	}
+	after := make(map[int]Transaction, len(current))
+	for _, trx := range current {
+		after[trx.Line] = trx
+	}
+
+	var added, removed []Transaction
+	for _, trx := range current {
+		if _, ok := before[trx.Line]; !ok {
+			added = append(added, trx)
+		}
+	}
+	for _, trx := range previous {
+		if _, ok := after[trx.Line]; !ok {
+			removed = append(removed, trx)
+		}
+	}
+	fmt.Fprintf(w, "+%d newly matched, -%d no longer matched\n", len(added), len(removed))
+	writeDiffRows(w, "+", added)
+	writeDiffRows(w, "-", removed)
+}
+
+func writeDiffRows(w io.Writer, mark string, transactions []Transaction) {
+	sort.SliceStable(transactions, func(i, j int) bool { return transactions[i].Line < transactions[j].Line })
+	for _, trx := range transactions {
+		fmt.Fprintln(w, " ", mark, "line", trx.Line, trx.Date.Format(dateEntry), trx.Desc+":", formatAmount(trx.Amount))
+	}
+}
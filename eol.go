@@ -0,0 +1,36 @@
+package main
+
+// Line endings from old systems with -fix-eol: files that end their lines with a bare \r read as one giant
+// record, so every \r and \r\n is turned into \n before the csv reader sees them
+
+import "io"
+
+// Turns \r and \r\n line endings into \n as the file is read
+type eolReader struct {
+	r      io.Reader
+	lastCR bool //The previous chunk ended with \r, so a \n at the start of this one belongs to it
+}
+
+func (e *eolReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	out := 0
+	for _, b := range p[:n] {
+		switch {
+		case b == '\n' && e.lastCR:
+			e.lastCR = false
+			continue
+		case b == '\r':
+			e.lastCR = true
+			b = '\n'
+		default:
+			e.lastCR = false
+		}
+		p[out] = b
+		out++
+	}
+	//Readers shouldn't return nothing with no error, so read on if the only byte was a dropped \n
+	if out == 0 && n > 0 && err == nil {
+		return e.Read(p)
+	}
+	return out, err
+}
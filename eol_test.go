@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Returns its chunks one Read at a time, to control where the reads split the input
+type chunkReader struct {
+	chunks []string
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if len(c.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, c.chunks[0])
+	c.chunks[0] = c.chunks[0][n:]
+	if c.chunks[0] == "" {
+		c.chunks = c.chunks[1:]
+	}
+	return n, nil
+}
+
+func TestEOLReaderBareCR(t *testing.T) {
+	input := "Date Trx,Description,Debit\r03-Jul-23,frais SMS,10.00\r04-Jul-23,taxes,3.25\r"
+	rows, err := csv.NewReader(&eolReader{r: strings.NewReader(input)}).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"Date Trx", "Description", "Debit"}, {"03-Jul-23", "frais SMS", "10.00"}, {"04-Jul-23", "taxes", "3.25"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q; want %q", rows, want)
+	}
+}
+
+func TestEOLReaderSplitReads(t *testing.T) {
+	//The first \r\n straddles two reads, and the second read is only the \n that gets dropped
+	reader := &eolReader{r: &chunkReader{chunks: []string{"a,b\r", "\n", "c,d\r", "\ne,f\r\r", "g,h\n"}}}
+	out, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a,b\nc,d\ne,f\n\ng,h\n"; string(out) != want {
+		t.Errorf("read %q; want %q", out, want)
+	}
+}
+
+func TestEOLReaderDroppedNewlineOnly(t *testing.T) {
+	reader := &eolReader{r: &chunkReader{chunks: []string{"a\r", "\n", "b"}}}
+	p := make([]byte, 16)
+	if n, err := reader.Read(p); n != 2 || err != nil {
+		t.Fatalf("first Read = %d, %v", n, err)
+	}
+	//The next chunk is only the \n of the \r\n, so Read must go on to the one after rather than return 0
+	n, err := reader.Read(p)
+	if n != 1 || err != nil || p[0] != 'b' {
+		t.Errorf("second Read = %d, %v, %q; want 1 byte \"b\"", n, err, p[:n])
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+// Writes the fees found to a spreadsheet-friendly file with -export: .csv, or tab separated for .tsv
+// -split-export writes a .csv for each fee word instead, for sending each kind of fee to whoever deals with it
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Characters that can't be in a file name on Windows, replaced when a fee word is used as one
+var fileNameReplacer = strings.NewReplacer("/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_")
+
+// Writes one row per fee, in the order given. With -cumulative each row also has the running total up to and including it
+func writeExport(path string, transactions []Transaction) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	comma := ','
+	if strings.EqualFold(filepath.Ext(path), ".tsv") {
+		comma = '\t'
+	}
+	out := newCSVOutput(file, comma)
+	for _, trx := range transactions {
+		out.WriteTransaction(trx)
+	}
+	out.WriteSummary(Result{})
+	if err := out.writer.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Writes one .csv per fee word into dir, named after the word, e.g. frais.csv, each ending with a total row
+// Returns the names of the files written
+func writeSplitExport(dir string, transactions []Transaction) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	byKeyword := make(map[string][]Transaction)
+	for _, trx := range transactions {
+		byKeyword[trx.Keyword] = append(byKeyword[trx.Keyword], trx)
+	}
+	var names []string
+	for _, keyword := range sortedKeys(byKeyword) {
+		name := filepath.Join(dir, keywordFileName(keyword)+".csv")
+		if err := writeKeywordExport(name, byKeyword[keyword]); err != nil {
+			return names, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func writeKeywordExport(path string, transactions []Transaction) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	out := newCSVOutput(file, ',')
+	total := 0.0
+	for _, trx := range transactions {
+		out.WriteTransaction(trx)
+		total += trx.Amount
+	}
+	out.writer.Write([]string{"", "", "Total", "", machineAmount(total)})
+	out.WriteSummary(Result{})
+	if err := out.writer.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Turns a fee word into a file name, e.g. "commis." into "commis"
+func keywordFileName(keyword string) string {
+	name := strings.Trim(fileNameReplacer.Replace(keyword), ". ")
+	if name == "" {
+		name = "fees"
+	}
+	return name
+}
@@ -0,0 +1,48 @@
+package main
+
+// Fees recognised by their amount instead of their description, with -fee-amounts: some banks take round
+// service charges like 10.00 or 25.00 under descriptions that have no fee word in them
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Parsed from -fee-amounts; nil when only the fee words are used
+var feeAmounts []float64
+
+// Parses a comma separated list of amounts, e.g. "10,25.00"
+func parseFeeAmounts(list string) ([]float64, error) {
+	var amounts []float64
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		amount, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, errors.New("\"" + field + "\" is not an amount.")
+		}
+		amounts = append(amounts, amount)
+	}
+	return amounts, nil
+}
+
+// Returns the match reason for a debit of one of the fee amounts, e.g. "amount 10.00", or "" if it isn't one
+// Credits leave the amount cell empty, so they never match
+func matchFeeAmount(cell string) string {
+	if strings.TrimSpace(cell) == "" {
+		return ""
+	}
+	amount, _, err := parseAmount(cell)
+	if err != nil {
+		return ""
+	}
+	for _, feeAmount := range feeAmounts {
+		if nearlyEqual(amount, feeAmount) {
+			return "amount " + machineAmount(feeAmount) //The reason ends up in the keyword field of machine-read output
+		}
+	}
+	return ""
+}
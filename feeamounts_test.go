@@ -0,0 +1,16 @@
+package main
+
+import "testing"
+
+func TestMatchFeeAmountMachineFormat(t *testing.T) {
+	defer func(amounts []float64, sep string) { feeAmounts, *decimalSepFlag = amounts, sep }(feeAmounts, *decimalSepFlag)
+	feeAmounts = []float64{10, 25}
+	*decimalSepFlag = ","
+
+	if keyword := matchFeeAmount("10.00"); keyword != "amount 10.00" {
+		t.Errorf("matchFeeAmount(10.00) = %q; want \"amount 10.00\" whatever -decimal-sep is", keyword)
+	}
+	if keyword := matchFeeAmount("12.00"); keyword != "" {
+		t.Errorf("matchFeeAmount(12.00) = %q; want no match", keyword)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+// Fixed-width export with -fixed-out, for older accounting systems that import positional text instead of .csv.
+// Each fee is one line: the date and the description padded or cut to their width, and the amount right-aligned.
+// Lines end with \r\n, since the programs that read these are mostly on Windows.
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Parsed from -fixed-widths
+var fixedWidths [3]int
+
+// Parses -fixed-widths, the widths of the date, description and amount columns, e.g. "10,30,12"
+func parseFixedWidths(list string) ([3]int, error) {
+	var widths [3]int
+	fields := strings.Split(list, ",")
+	if len(fields) != 3 {
+		return widths, errors.New("give three widths separated by commas: date, description and amount.")
+	}
+	for i, field := range fields {
+		width, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || width < 1 {
+			return widths, fmt.Errorf("%q is not a width.", field)
+		}
+		widths[i] = width
+	}
+	return widths, nil
+}
+
+// Writes one line per fee to path. Amounts are written as plain numbers, whatever -decimal-sep is, since a
+// program reads them; one that doesn't fit its column is an error rather than being cut short.
+func writeFixedWidth(path string, transactions []Transaction, widths [3]int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	for _, trx := range transactions {
+		amount := machineAmount(trx.Amount)
+		if len(amount) > widths[2] {
+			return fmt.Errorf("the amount %s on %s doesn't fit in %d characters.", amount, trx.Date.Format(dateEntry), widths[2])
+		}
+		fmt.Fprintf(w, "%s%s%*s\r\n", fixedField(trx.Date.Format(dateEntry), widths[0]), fixedField(trx.Desc, widths[1]), widths[2], amount)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Pads text with spaces, or cuts it, to exactly width characters
+func fixedField(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+	return text + strings.Repeat(" ", width-len(runes))
+}
@@ -0,0 +1,44 @@
+package main
+
+// Formatting amounts. Everything shown to people goes through formatAmount so that every report uses the same
+// separators, set with -decimal-sep and -thousands-sep (e.g. "1 234,56"). Everything read by programs (JSON, csv,
+// tsv, logfmt and fixed-width files) goes through machineAmount instead, so those settings can't break them.
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Formats an amount to two decimals with the -decimal-sep and -thousands-sep separators
+func formatAmount(amount float64) string {
+	text := strconv.FormatFloat(amount, 'f', 2, 64)
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign = "-"
+		text = text[1:]
+	}
+	whole, cents, _ := strings.Cut(text, ".")
+	if *thousandsSepFlag != "" {
+		var groups []string
+		for len(whole) > 3 {
+			groups = append([]string{whole[len(whole)-3:]}, groups...)
+			whole = whole[:len(whole)-3]
+		}
+		whole = strings.Join(append([]string{whole}, groups...), *thousandsSepFlag)
+	}
+	return sign + whole + *decimalSepFlag + cents
+}
+
+// Formats an amount to two decimals with a point and no thousands separator, whatever the separator flags say
+// A total that comes out as a tiny negative from floating point residue is written as 0.00, not -0.00
+func machineAmount(amount float64) string {
+	if isZero(amount) {
+		amount = 0
+	}
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// Formats a percentage to one decimal with the -decimal-sep separator
+func formatPercent(pct float64) string {
+	return strings.Replace(strconv.FormatFloat(pct, 'f', 1, 64), ".", *decimalSepFlag, 1)
+}
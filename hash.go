@@ -0,0 +1,54 @@
+package main
+
+// Fingerprints each input file with -hash, so a report can be matched to the exact file it came from.
+// The file is hashed as it's read rather than read a second time, which matters for web addresses.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"sync"
+)
+
+// SHA-256 of each file read so far, keyed by the file argument; files are read in parallel in the multi-file mode
+var inputHashes = make(map[string]string)
+var inputHashesMu sync.Mutex
+
+// Hashes everything read through it, and records the hash when closed
+type hashingReader struct {
+	io.ReadCloser
+	name string
+	sum  hash.Hash
+}
+
+func newHashingReader(name string, input io.ReadCloser) *hashingReader {
+	return &hashingReader{ReadCloser: input, name: name, sum: sha256.New()}
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.sum.Write(p[:n])
+	return n, err
+}
+
+// Hashes whatever is left unread first, so the hash is always of the whole file
+func (r *hashingReader) Close() error {
+	_, err := io.Copy(r.sum, r.ReadCloser)
+	if err == nil {
+		inputHashesMu.Lock()
+		inputHashes[r.name] = hex.EncodeToString(r.sum.Sum(nil))
+		inputHashesMu.Unlock()
+	}
+	if closeErr := r.ReadCloser.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Returns the SHA-256 of a file that has been read, or "" without -hash
+func inputHash(name string) string {
+	inputHashesMu.Lock()
+	defer inputHashesMu.Unlock()
+	return inputHashes[name]
+}
@@ -0,0 +1,136 @@
+package main
+
+// Shows what the program makes of a file with -inspect, for working out why fees aren't being found
+// Nothing is calculated; it only reads the file and reports how it was understood.
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Number of data rows -inspect shows
+const inspectRows = 5
+
+// Prints the file's encoding and delimiter, the header with the role of each column, and the first few rows
+func inspectFile(w io.Writer, currFile string) error {
+	header, data, err := readFile(currFile)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "File:", filepath.Base(currFile))
+	if strings.EqualFold(inputExt(currFile), ".xlsx") {
+		sheet := *sheetFlag
+		if sheet == "" {
+			sheet = "the first one"
+		}
+		fmt.Fprintln(w, "Format: Excel workbook, sheet", sheet)
+	} else {
+		input, err := openInput(currFile)
+		if err != nil {
+			return err
+		}
+		raw, err := io.ReadAll(input)
+		input.Close()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "Encoding:", fileEncoding(raw))
+		fmt.Fprintln(w, "Delimiter: comma")
+		//A single column usually means the file was saved with another delimiter
+		if len(header) == 1 {
+			for _, delim := range []string{";", "\t", "|"} {
+				if strings.Contains(header[0], delim) {
+					fmt.Fprintf(w, "The header is a single column containing %q; the file may use that as its delimiter instead of a comma.\n", delim)
+					break
+				}
+			}
+		}
+	}
+
+	colDate := getindex(header, *dateColFlag)
+	if colDate >= 0 && len(data) > 1 {
+		layout, err := fileDateFormat(data[1:], colDate)
+		if err != nil {
+			fmt.Fprintln(w, "Date format:", err)
+		} else {
+			fmt.Fprintln(w, "Date format:", layout)
+		}
+	}
+
+	fmt.Fprintln(w, "Columns:")
+	roles := columnRoles(header)
+	for i, name := range header {
+		if roles[i] == "" {
+			fmt.Fprintf(w, "  %d. %s\n", i+1, name)
+		} else {
+			fmt.Fprintf(w, "  %d. %s: %s\n", i+1, name, roles[i])
+		}
+	}
+	for _, col := range []struct {
+		name string
+		role string
+	}{{*dateColFlag, "date"}, {*descColFlag, "description"}, {*amntColFlag, "amount"}} {
+		if getindex(header, col.name) < 0 {
+			fmt.Fprintf(w, "No column matches the %s header \"%s\".\n", col.role, col.name)
+		}
+	}
+
+	fmt.Fprintln(w, "First rows:")
+	for i, row := range data {
+		if i == inspectRows+1 {
+			break
+		}
+		//The first row after the header is never processed
+		if i == 0 {
+			fmt.Fprintln(w, "  (not processed)", strings.Join(row, " | "))
+			continue
+		}
+		fmt.Fprintf(w, "  line %d: %s\n", i, strings.Join(row, " | "))
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+// Works out what each header column is used for; "" for columns that aren't used
+func columnRoles(header []string) []string {
+	roles := make([]string, len(header))
+	add := func(col int, role string) {
+		if col < 0 {
+			return
+		}
+		if roles[col] != "" {
+			roles[col] += ", "
+		}
+		roles[col] += role
+	}
+	add(getindex(header, *dateColFlag), "date")
+	add(getindex(header, *descColFlag), "description")
+	add(getindex(header, *amntColFlag), "amount")
+	add(getindex(header, refField), "reference")
+	add(getindex(header, curField), "currency")
+	for _, profile := range config.Reports {
+		if profile.Column != "" {
+			add(getindex(header, profile.Column), "report \""+profile.Name+"\"")
+		}
+	}
+	return roles
+}
+
+// Describes the text encoding of a file from its bytes
+func fileEncoding(raw []byte) string {
+	switch {
+	case bytes.HasPrefix(raw, []byte{0xEF, 0xBB, 0xBF}):
+		return "UTF-8 with a byte order mark"
+	case bytes.HasPrefix(raw, []byte{0xFF, 0xFE}), bytes.HasPrefix(raw, []byte{0xFE, 0xFF}):
+		return "UTF-16, which is not supported; save the file as UTF-8"
+	case utf8.Valid(raw):
+		return "UTF-8"
+	default:
+		return "not UTF-8, probably Windows-1252; accented fee words will not match"
+	}
+}
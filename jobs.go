@@ -0,0 +1,94 @@
+package main
+
+// Batch mode: -jobs runs a list of files and date ranges from a JSON file without any prompts, e.g.
+//
+//	[
+//	  {"file": "courant.csv", "start": "2023-07-01", "end": "m", "output": "courant-july.txt"},
+//	  {"file": "epargne.csv", "start": "2023-07-01", "end": "2023-07-15"}
+//	]
+//
+// "end" can be a date or 'q'/'m' for the end of the quinzaine or month, as at the prompt.
+// Jobs without an "output" write their report to the screen.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+type Job struct {
+	File   string `json:"file"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Output string `json:"output"`
+}
+
+// Runs each job in the jobs file in turn, reporting any that fail and carrying on with the rest
+// Any failure sets the exit status, so scripts running a batch can tell
+func runJobs(path string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fail("Could not read the jobs file:", err)
+		return
+	}
+	var jobs []Job
+	if err := json.Unmarshal(content, &jobs); err != nil {
+		fail("Could not read the jobs file:", err)
+		return
+	}
+
+	failed := 0
+	for i, job := range jobs {
+		if err := runJob(job); err != nil {
+			fail(fmt.Sprintf("Job %d (%s) failed: %v", i+1, job.File, err))
+			failed += 1
+		}
+	}
+	//Quiet runs keep stdout for the reports themselves
+	var w io.Writer = os.Stdout
+	if *quietFlag {
+		w = os.Stderr
+	}
+	fmt.Fprintln(w, "Ran", len(jobs), "jobs,", failed, "failed.")
+}
+
+// Runs one job through the same calculation as the interactive mode and writes its report
+func runJob(job Job) error {
+	date1, date2, err := jobDates(job)
+	if err != nil {
+		return err
+	}
+
+	header, data, err := readFile(job.File)
+	if err != nil {
+		return err
+	}
+	res, err := calculate(header, data, date1, date2, false)
+	if err != nil {
+		return err
+	}
+	res.setFile(job.File)
+
+	if job.Output == "" {
+		writeJobReport(os.Stdout, job, res, date1, date2)
+		return nil
+	}
+	//Written in one go, so a failed write or close (e.g. a full disk) fails the job instead of leaving half a report
+	var report bytes.Buffer
+	writeJobReport(&report, job, res, date1, date2)
+	return os.WriteFile(job.Output, report.Bytes(), 0644)
+}
+
+func writeJobReport(w io.Writer, job Job, res Result, date1 time.Time, date2 time.Time) {
+	fmt.Fprintln(w, "File:", job.File)
+	fmt.Fprintln(w, "Transactions from", date1.Format("02 Jan 2006"), "to", date2.Format("02 Jan 2006"))
+	writeSummary(w, res)
+}
+
+// Parses a job's start and end dates
+func jobDates(job Job) (time.Time, time.Time, error) {
+	return parseRange(job.Start, job.End)
+}
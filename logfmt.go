@@ -0,0 +1,69 @@
+package main
+
+// One key=value line per run for log aggregation with -format logfmt, e.g.
+//
+//	file=x.csv period=2023-07-01..2023-07-31 fees=1234.56 txns=42 lines=2000
+//
+// In the multi-file mode each file gets its own line, followed by one for all of them together.
+// Fees in more than one currency are written as fees_HTG=, fees_USD= and so on instead of fees=.
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type logfmtOutput struct {
+	w    io.Writer
+	info outputInfo
+}
+
+func (o logfmtOutput) WriteTransaction(trx Transaction) {}
+
+func (o logfmtOutput) WriteSummary(res Result) {
+	for _, fileRes := range o.info.Results {
+		writeLogfmt(o.w, filepath.Base(fileRes.File), o.info.Start, o.info.End, fileRes)
+	}
+	writeLogfmt(o.w, o.info.Name, o.info.Start, o.info.End, res)
+}
+
+func writeLogfmt(w io.Writer, name string, date1 time.Time, date2 time.Time, res Result) {
+	fields := []string{
+		"file=" + logfmtValue(name),
+		"period=" + date1.Format(dateEntry) + ".." + date2.Format(dateEntry),
+	}
+	//Currencies are never added together, so each one gets its own field, e.g. fees_HTG=10.00 fees_USD=7.00
+	if len(res.ByCurrency) > 1 {
+		for _, currency := range sortedKeys(res.ByCurrency) {
+			key := currency
+			if key == "" {
+				key = "none"
+			}
+			fields = append(fields, "fees_"+key+"="+machineAmount(res.ByCurrency[currency]))
+		}
+	} else {
+		fields = append(fields, "fees="+machineAmount(res.Total))
+	}
+	fields = append(fields, "txns="+strconv.Itoa(len(res.Transactions)), "lines="+strconv.Itoa(res.Lines))
+	if res.InterestCount > 0 {
+		fields = append(fields, "interest="+machineAmount(res.Interest))
+	}
+	if res.Skipped > 0 {
+		fields = append(fields, "skipped="+strconv.Itoa(res.Skipped))
+	}
+	if res.Err != nil {
+		fields = append(fields, "error="+logfmtValue(res.Err.Error()))
+	}
+	fmt.Fprintln(w, strings.Join(fields, " "))
+}
+
+// Quotes a value if it has spaces, quotes or an equals sign in it, or is empty
+func logfmtValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\"=") {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -9,20 +9,34 @@ package main
 //Current as of July 2023
 
 import (
+	"bufio"
 	"encoding/csv"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
-	"log"
+	"math"
 	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Constants for the file headers. Change these if the headers change in the output files
+// The first three can also be overridden for one run with -date-col, -desc-col and -amnt-col (or -cols)
 const dateField string = "Date Trx"    //Transaction Date header
 const descField string = "Description" //Transaction Description header
 const amntField string = "Debit"       //Transaction Value header
+const refField string = "Reference"    //Transaction Reference header; only needed when using -ref
+const curField string = "Devise"       //Transaction Currency header; optional, totals are split by currency if it's there
 
 // Date format constants
 // See "Golang time.Parse date format" if needing to change these
@@ -32,162 +46,1795 @@ const dateEntry = "2006-01-02" //Format for user-entered dates; default is ISO
 // Verbose: Do you want it on?
 const verbose = false
 
+// Locale used when spelling out the total with -words ("fr" or "en") if -lang isn't given
+const wordsLocale = "fr"
+
+// Command line flags. These are all optional so drag-and-drop keeps working; they must come before the file names
+var dateColFlag = flag.String("date-col", dateField, "Header of the transaction date column")
+var descColFlag = flag.String("desc-col", descField, "Header of the transaction description column")
+var amntColFlag = flag.String("amnt-col", amntField, "Header of the transaction value column")
+var colsFlag = flag.String("cols", "", "Date, description and value headers in one go, e.g. \"Date Trx,Description,Debit\"; quote a header that has a comma, e.g. '\"Date, Trx\",Description,Debit'")
+var startFlag = flag.String("start", "", "Beginning date (yyyy-mm-dd); skips the date prompts")
+var endFlag = flag.String("end", "m", "Ending date (yyyy-mm-dd), or 'q'/'m' for the end of the quinzaine/month; used with -start")
+var dateFlag = flag.String("date", "", "A single day (yyyy-mm-dd) to process instead of -start and -end; skips the date prompts")
+var quietFlag = flag.Bool("quiet", false, "Only print the result; use with -start")
+var rawFlag = flag.Bool("raw", false, "Print the total as a bare number")
+var bareFlag = flag.Bool("bare", false, "Print only the total with no label and no newline, for TOTAL=$(ubnkparse ...); implies -quiet")
+var countFlag = flag.Bool("count", false, "Also print the number of fee transactions found")
+var langFlag = flag.String("lang", "", "Language for the prompts: en or fr (default: the original English prompts)")
+var templateFlag = flag.String("template", "", "Go text/template for the summary, e.g. \"{{.File}} {{.Start}}..{{.End}}: {{.Total}} ({{.Count}} fees)\"")
+var wordsFlag = flag.Bool("words", false, "Also print the total spelled out in words")
+var nonFeesFlag = flag.Bool("non-fees", false, "Also total the debits in the date range that are not fees")
+var configFlag = flag.String("config", "", "Path to the config file (default: "+configFile+" next to the program)")
+var chartFlag = flag.Bool("chart", false, "Print a bar chart of the fees for each month")
+var chartWidthFlag = flag.Int("chart-width", 40, "Length of the longest bar in the -chart bar chart")
+var pieFlag = flag.Bool("pie", false, "Print each fee word's share of the total as a line of -chart-width characters")
+var weekdaysOnlyFlag = flag.Bool("weekdays-only", false, "Leave out transactions dated on a Saturday or Sunday")
+var limitFlag = flag.Int("limit", 0, "Only process the first N lines of each file (0 for all)")
+var peakDayFlag = flag.Bool("peak-day", false, "Show the day with the highest fee total")
+var sheetFlag = flag.String("sheet", "", "Name or number of the sheet to read from .xlsx files (default: the first sheet)")
+var epsilonFlag = flag.Float64("epsilon", 0.005, "Amounts closer than this are treated as equal; adjust for currencies with other minor units")
+var reviewFlag = flag.Bool("review", false, "Ask before counting each match on an ambiguous fee word (single file only)")
+var skipLinesFlag = flag.Int("skip-lines", 0, "Number of lines before the header row to ignore")
+var wholeWordFlag = flag.Bool("whole-word", false, "Only match fee words on their own, not inside longer words")
+var scheduleFlag = flag.String("schedule", "", "Fee schedule .csv (fee word, expected amount) to check the fees against")
+var absFlag = flag.Bool("abs", false, "Count negative fee amounts as positive instead of warning about them")
+var compareRangeFlag = flag.String("compare-range", "", "Second date range start:end to compare the total against, e.g. 2023-06-01:m")
+var formatFlag = flag.String("format", "text", "Output format: text, json, ndjson, csv, tsv, markdown, logfmt or csv-summary; use with -quiet to get only the output")
+var markdownFlag = flag.Bool("markdown", false, "Same as -format markdown")
+var csvSummaryFlag = flag.Bool("csv-summary", false, "Same as -format csv-summary: only a header and one CSV row with the file, dates, total, count and lines; fees in more than one currency are refused")
+var logfmtFlag = flag.Bool("logfmt", false, "Same as -format logfmt: one key=value line with the result, for log aggregation")
+var ndjsonFlag = flag.Bool("ndjson", false, "Same as -format ndjson: one JSON object per fee, then a summary object, written once the file has been read")
+var exportFlag = flag.String("export", "", "Write the fees found to this .csv or .tsv file")
+var cumulativeFlag = flag.Bool("cumulative", false, "Add a running total column to -export files and csv/tsv output")
+var detectHeaderFlag = flag.Bool("detect-header", false, "Find the header row among the first lines instead of assuming it's the first")
+var strictFlag = flag.Bool("strict", false, "Stop with an error at the first line with missing fields or a bad date or amount, instead of skipping it")
+var counterpartyFlag = flag.Bool("group-by-counterparty", false, "Also subtotal the fees by the counterparty at the end of the description")
+var counterpartySepFlag = flag.String("counterparty-sep", " - ", "Text that comes before the counterparty in descriptions, for -group-by-counterparty")
+var inspectFlag = flag.Bool("inspect", false, "Show how each file is read (encoding, delimiter, columns, first rows) without calculating anything")
+var normalizeFlag = flag.Bool("normalize", false, "Ignore accents, punctuation and extra spaces when matching fee words")
+var collapseFlag = flag.Bool("collapse", false, "In fee listings, merge runs of the same fee (description and amount) into one line")
+var collapseAllFlag = flag.Bool("collapse-all", false, "Like -collapse, but merge the same fee wherever it appears in the listing, not just when repeated in a row")
+var pctDebitsFlag = flag.Bool("pct-debits", false, "Also show the fee total as a percentage of all debits in the date range")
+var basicAuthFlag = flag.String("basic-auth", "", "user:password for statements read from an http:// or https:// address")
+var dedupFlag = flag.Bool("dedup", false, "In the multi-file mode, leave out fees that an earlier file already had (same date, description and amount). Only the fees are deduplicated; interest, non-fee debits, balances and report totals still count every file's lines")
+var unusedKeywordsFlag = flag.Bool("unused-keywords", false, "List the fee words that didn't match any fee, as a hint for pruning feewords.txt")
+var showConfigFlag = flag.Bool("show-config", false, "Print the settings in effect (columns, formats, word lists, flags and dates) before processing")
+var sinceLastFlag = flag.Bool("since-last", false, "Process from the day after the last run on this file up to today, and remember where it got to")
+var verifySubtotalsFlag = flag.Bool("verify-subtotals", false, "Check that the rows between subtotal rows add up to each subtotal")
+var subtotalMarkerFlag = flag.String("subtotal-marker", "SOUS-TOTAL", "Text in the description that marks a subtotal row, for -verify-subtotals")
+var pivotFlag = flag.String("pivot", "", "Write a table of the fees by month and fee word to this .csv or .tsv file")
+var blankAsZeroFlag = flag.Bool("blank-as-zero", false, "Count a fee with an empty amount as 0.00 instead of skipping it with a warning")
+var tuneFlag = flag.Bool("interactive", false, "Tune the fee words on one file: add and remove words and see the total change straight away")
+var suggestFlag = flag.Bool("suggest", false, "List descriptions that matched no fee word but look like fees, to help find words to add")
+var suggestDistanceFlag = flag.Int("suggest-distance", 2, "For -suggest, how many letters a word can differ from a fee word and still look like it")
+var suggestMaxFlag = flag.Float64("suggest-max", 100, "For -suggest, the largest amount written in a description that looks like a fee")
+var byWeekdayFlag = flag.Bool("by-weekday", false, "Show the number and total of fees for each day of the week")
+var decimalSepFlag = flag.String("decimal-sep", ".", "Separator between the whole number and the cents when showing amounts")
+var thousandsSepFlag = flag.String("thousands-sep", "", "Separator between groups of thousands when showing amounts, e.g. \" \" or \",\" (default none)")
+var hashFlag = flag.Bool("hash", false, "Show the SHA-256 of each input file in the report, to prove which file the totals came from")
+var previewFlag = flag.Int("preview", 0, "Show the first and last N fees found, to check the range caught the right ones")
+var tzFlag = flag.String("tz", "Local", "Time zone for the dates, as an IANA name like America/Port-au-Prince or UTC; Local is this computer's zone")
+var feeAmountsFlag = flag.String("fee-amounts", "", "Comma separated amounts, e.g. 10,25, that count as fees whatever the description says")
+var combinedColFlag = flag.String("combined-col", "", "Column that holds the description and the amount together, like \"FRAIS SMS 10.00\"; the number at the end is used as the amount")
+var onlyKeywordFlag = flag.String("only-keyword", "", "Only list the fees matched by this fee word; the total still counts every fee unless -only-keyword-total is set")
+var onlyKeywordTotalFlag = flag.Bool("only-keyword-total", false, "With -only-keyword, count only that fee word's fees in the totals too")
+var clipboardFlag = flag.Bool("clipboard", false, "Copy the fee total to the clipboard (Windows and macOS)")
+var maxSpanFlag = flag.Int("max-span-days", 0, "Refuse a date range longer than this many days, or ask first when the dates are typed in; 0 for no limit")
+var distinctDaysFlag = flag.Bool("distinct-days", false, "Show on how many different days there were fees")
+var extraColFlag = flag.String("extra-col", "", "Another column, like a memo or reference, to carry through to the fees listed in csv, tsv and JSON output and -export")
+var medianFlag = flag.Bool("median", false, "Show the average and the median fee, since one large fee can skew the average")
+var splitExportFlag = flag.String("split-export", "", "Write the fees found for each fee word to its own .csv file in this folder, with a total row")
+var summaryDirFlag = flag.String("summary-dir", "", "Total every .csv file in this folder over the same dates, with each file's total and a combined summary")
+var fixEOLFlag = flag.Bool("fix-eol", false, "Read files whose lines end with a bare \\r (from old systems) as separate rows")
+var warnRaggedFlag = flag.Bool("warn-ragged", false, "Warn about rows with a different number of fields from the header, which can mean a damaged file")
+var dateShiftFlag = flag.Int("date-shift", 0, "Add this many days (or take them away if negative) to every date in the file before checking the range, for exports that label dates with a fixed offset; this changes which transactions are in the range")
+var noLoopFlag = flag.Bool("no-loop", false, "Still ask for the dates, but stop after one pass instead of offering to continue and waiting for a key")
+var debitsOnlyFlag = flag.Bool("debits-only", false, "Only count a fee word match when the row is a debit: a positive amount, with nothing in the -credit-col column")
+var creditColFlag = flag.String("credit-col", "Credit", "Header of the credit column, checked by -debits-only if the file has it")
+var statsFlag = flag.Bool("stats", false, "Finish with processing statistics: rows read, in range and matched, rows skipped for each reason, and the time taken")
+var fixedOutFlag = flag.String("fixed-out", "", "Write the fees found to this text file in fixed-width columns, for older accounting imports")
+var fixedWidthsFlag = flag.String("fixed-widths", "10,30,12", "Widths of the date, description and amount columns in -fixed-out files")
+var avgPerKeywordFlag = flag.Bool("avg-per-keyword", false, "Show each fee word's subtotal with its number of fees and the average fee")
+var baselineFlag = flag.String("baseline", "", "Compare the fee total with this file's fees over all of its dates, e.g. last month's export")
+var alertPctFlag = flag.Float64("alert-pct", 0, "With -baseline, fail with an alert if the fees are up by more than this percentage")
+var exactDescFlag = flag.String("exact-desc", "", "Only total rows whose description is exactly this (ignoring spaces at the ends), instead of matching fee words")
+var openingBalanceFlag = flag.String("opening-balance", "", "Balance before the first line in range; prints the opening and closing balances from the debits and credits in range")
+var descriptionsFlag = flag.Bool("descriptions", false, "List each distinct fee description with how many times it occurred and its subtotal, largest first")
+var maxSkipsFlag = flag.Int("max-skips", 0, "Stop with an error once more than N lines have been skipped in a file, as it is too damaged to trust (0 for no limit)")
+var keywordsFlag = flag.Bool("keywords", false, "Print the fee words in effect, one per line, and exit")
+var bankFormatFlag = flag.Bool("bank-format", false, "Write the summary in the layout of the bank's fee statement, to check against it side by side")
+var principalFlag = flag.Float64("principal", 0, "Loan or overdraft principal; prints the interest in range as an effective annual rate")
+var interestWordFlag = flag.String("interest-word", "", "A word that marks interest, added to the interest words; with -principal only its interest counts towards the rate")
+var yearPivotFlag = flag.Int("year-pivot", 0, "For dates with two-digit years: years below this are 20xx and the rest 19xx, e.g. 30 reads 29 as 2029 and 30 as 1930 (0 for Go's rule, which is 69)")
+var jobsFlag = flag.String("jobs", "", "Run every job in this jobs file instead of asking for dates")
+var sqliteFlag = flag.String("sqlite", "", "Save the matched transactions to this SQLite database; only in a copy built with -tags sqlite, which needs modernc.org/sqlite (see sqlite_driver.go)")
+var refFlag = flag.String("ref", "", "Only total rows whose reference matches this text or regular expression")
+
+// Parsed from -compare-range
+var compareDate1, compareDate2 time.Time
+
+// Time zone the range and the dates in the files are read in, from -tz
+var location = time.Local
+
+// Parsed from -template; nil when using the usual summary
+var summaryTemplate *template.Template
+
+// Compiled from -ref; nil when not filtering by reference
+var refPattern *regexp.Regexp
+
 // Function for which words to check for that indicate fees
 // If new words are added, include as many characters as possible to reduce ambiguity
 var feeList []string = initFeeList()
 
-func initFeeList() []string {
-	return []string{"commis.", "frais", "taxes", "timbre", "commissions"} //Add new words here as needed
-}
+func initFeeList() []string {
+	return loadWordList(feeFile, []string{"commis.", "frais", "taxes", "timbre", "commissions"}) //Add new words here as needed
+}
+
+// Words that mark a matched fee as a reversal (i.e. the bank giving the fee back)
+// A fee whose description also contains one of these is subtracted from the total instead of added
+var reversalList []string = initReversalList()
+
+func initReversalList() []string {
+	return loadWordList(reversalFile, []string{"annulation", "remboursement"}) //Add new words here as needed
+}
+
+// Words that indicate interest charges. These are totaled separately from the fees
+var interestList []string = initInterestList()
+
+func initInterestList() []string {
+	words := loadWordList(interestFile, []string{"intérêts", "intérêt", "interets", "interet"}) //Add new words here as needed
+	//The word from -interest-word is only known once the flags are parsed, when main builds the list again
+	if flag.Parsed() && *interestWordFlag != "" && indexOf(words, *interestWordFlag) < 0 {
+		words = append(words, *interestWordFlag)
+	}
+	return words
+}
+
+// Fee words that can also turn up in descriptions that aren't fees; with -review each match on one of these is confirmed by hand
+var ambiguousList []string = initAmbiguousList()
+
+func initAmbiguousList() []string {
+	return loadWordList(ambiguousFile, []string{"commis."}) //Add new words here as needed
+}
+
+// Descriptions, or parts of them, that are always fees even without a fee word, for one-off wordings
+// There are none built in, so this is only used when includewords.txt exists
+var includeList []string = loadWordList(includeFile, nil)
+
+// Reads the word list files again, so changes made to them since the program started are picked up
+func reloadWordLists() {
+	feeList = initFeeList()
+	reversalList = initReversalList()
+	interestList = initInterestList()
+	ambiguousList = initAmbiguousList()
+	includeList = loadWordList(includeFile, nil)
+}
+
+// Optional word list files, looked for in the same folder as the program
+// One word per line; blank lines and lines starting with # are ignored. If the file is missing the built-in words are used.
+const feeFile = "feewords.txt"
+const reversalFile = "reversalwords.txt"
+const ambiguousFile = "ambiguouswords.txt"
+const interestFile = "interestwords.txt"
+const includeFile = "includewords.txt"
+
+// Reads a word list from a file next to the program, falling back to defaults if the file doesn't exist or is empty
+func loadWordList(name string, defaults []string) []string {
+	content, err := os.ReadFile(programFile(name))
+	if err != nil {
+		return defaults
+	}
+
+	var words []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if len(words) == 0 {
+		return defaults
+	}
+	return words
+}
+
+func main() {
+
+	//Get args from the os (i.e. Windows drag and drop)
+	flag.Parse()
+	args := flag.Args()
+	interestList = initInterestList()
+
+	//Shows what will be matched, from feewords.txt or the built-in words, without processing anything
+	if *keywordsFlag {
+		for _, keyword := range feeList {
+			fmt.Println(keyword)
+		}
+		return
+	}
+
+	//These are meant to be the only thing on stdout
+	if *bareFlag || *logfmtFlag || *csvSummaryFlag {
+		*quietFlag = true
+	}
+	if !*quietFlag {
+		writeHeader()
+	}
+
+	if *colsFlag != "" {
+		cols, err := parseCols(*colsFlag)
+		if err != nil {
+			fail(err)
+			end()
+			os.Exit(exitCode)
+		}
+		*dateColFlag, *descColFlag, *amntColFlag = cols[0], cols[1], cols[2]
+	}
+
+	if *refFlag != "" {
+		refPattern = compileRef(*refFlag)
+	}
+	if *templateFlag != "" {
+		tmpl, err := template.New("summary").Parse(*templateFlag)
+		if err != nil {
+			fail("The -template is not valid:", err)
+			os.Exit(exitCode)
+		}
+		summaryTemplate = tmpl
+	}
+	if *bankFormatFlag {
+		if *templateFlag != "" {
+			fail("-bank-format and -template can't be used together; -bank-format is a template of its own.")
+			end()
+			os.Exit(exitCode)
+		}
+		summaryTemplate = parseBankTemplate()
+	}
+
+	if *markdownFlag {
+		*formatFlag = "markdown"
+	}
+	if *logfmtFlag {
+		*formatFlag = "logfmt"
+	}
+	if *csvSummaryFlag {
+		*formatFlag = "csv-summary"
+	}
+	if *ndjsonFlag {
+		*formatFlag = "ndjson"
+	}
+	if !validFormat(*formatFlag) {
+		fail("The -format must be one of:", strings.Join(outputFormats, ", "))
+		end()
+		os.Exit(exitCode)
+	}
+
+	if err := checkChartWidth(*chartWidthFlag); err != nil && (*chartFlag || *pieFlag) {
+		fail(err)
+		end()
+		os.Exit(exitCode)
+	}
+
+	if *yearPivotFlag < 0 || *yearPivotFlag > 100 {
+		fail("The -year-pivot must be from 0 to 100.")
+		end()
+		os.Exit(exitCode)
+	}
+
+	if tz, err := time.LoadLocation(*tzFlag); err != nil {
+		fail("The -tz is not a known time zone:", *tzFlag)
+		end()
+		os.Exit(exitCode)
+	} else {
+		location = tz
+	}
+
+	if *compareRangeFlag != "" {
+		start, finish, _ := strings.Cut(*compareRangeFlag, ":")
+		date1, date2, err := parseRange(start, finish)
+		if err != nil {
+			fail("The -compare-range is not valid:", err)
+			end()
+			os.Exit(exitCode)
+		}
+		compareDate1, compareDate2 = date1, date2
+	}
+
+	//Saving comes after all the processing, so a missing driver is better found out now
+	if *sqliteFlag != "" && !sqliteAvailable() {
+		fail("-sqlite can't be used:", errNoSQLite)
+		end()
+		os.Exit(exitCode)
+	}
+
+	if *fixedOutFlag != "" {
+		widths, err := parseFixedWidths(*fixedWidthsFlag)
+		if err != nil {
+			fail("The -fixed-widths are not valid:", err)
+			end()
+			os.Exit(exitCode)
+		}
+		fixedWidths = widths
+	}
+
+	if *feeAmountsFlag != "" {
+		amounts, err := parseFeeAmounts(*feeAmountsFlag)
+		if err != nil {
+			fail("The -fee-amounts are not valid:", err)
+			end()
+			os.Exit(exitCode)
+		}
+		feeAmounts = amounts
+	}
+
+	if *openingBalanceFlag != "" {
+		balance, _, err := parseAmount(*openingBalanceFlag)
+		if err != nil {
+			fail("The -opening-balance is not a number:", *openingBalanceFlag)
+			end()
+			os.Exit(exitCode)
+		}
+		openingBalance = balance
+	}
+
+	if *scheduleFlag != "" {
+		schedule, err := loadSchedule(*scheduleFlag)
+		if err != nil {
+			fail("Could not read the fee schedule:", err)
+			end()
+			os.Exit(exitCode)
+		}
+		feeSchedule = schedule
+	}
+
+	var err error
+	config, err = loadConfig(*configFlag)
+	if err != nil {
+		fmt.Println("Could not read the config file:", err)
+		end()
+		return
+	}
+
+	//A jobs file replaces the interactive prompts entirely
+	if *jobsFlag != "" {
+		runJobs(*jobsFlag)
+		os.Exit(exitCode)
+	}
+
+	//A whole folder of exports always gets the combined report, even if there's only one file in it this time
+	if *summaryDirFlag != "" {
+		matches, err := filepath.Glob(filepath.Join(*summaryDirFlag, "*.csv"))
+		if err == nil && len(matches) == 0 {
+			err = errors.New("there are no .csv files in " + *summaryDirFlag + ".")
+		}
+		if err != nil {
+			fail("Could not read the -summary-dir:", err)
+			end()
+			os.Exit(exitCode)
+		}
+		processMulti(matches)
+		end()
+		os.Exit(exitCode)
+	}
+
+	//Folders dragged onto the program are expanded into the .csv and .xlsx files they contain
+	files, err := expandArgs(args)
+	if err != nil {
+		fmt.Println("Could not read the folder:", err)
+		end()
+		return
+	}
+	argct := len(files)
+
+	if *inspectFlag {
+		for _, currFile := range files {
+			if err := inspectFile(os.Stdout, currFile); err != nil {
+				fail(filepath.Base(currFile)+":", err)
+			}
+		}
+		end()
+		os.Exit(exitCode)
+	}
+
+	//Check number of args received to make sure we received at least one file.
+	//Ideally no args would open a file open ui, but there's nothing in the standard library and we're trying to avoid going outside that
+	//More than one file switches to the multi-file mode, which totals every file over the same dates
+	switch {
+	case argct < 1:
+		fail(msg("dragDrop"))
+		end()
+		os.Exit(exitCode)
+	case argct > 1 && *tuneFlag:
+		fail("-interactive works on one file at a time.")
+		os.Exit(exitCode)
+	case argct > 1 && *sinceLastFlag:
+		fail("-since-last works on one file at a time, since each file has its own last run.")
+		os.Exit(exitCode)
+	case argct > 1:
+		processMulti(files)
+		end()
+		os.Exit(exitCode)
+	}
+
+	for _, currFile := range files {
+		//The file is only read once; continuing with new dates recalculates from the rows already in memory
+		header, data, err := readFile(currFile)
+		if err != nil {
+			fail(err)
+			end()
+			continue
+		}
+		if *tuneFlag {
+			date1, date2, err := rangeDates()
+			if err != nil {
+				fail(err)
+				continue
+			}
+			tuneKeywords(header, data, date1, date2)
+			continue
+		}
+		previousPass = nil
+		i := -1
+		for i != 0 {
+			i = process(currFile, header, data)
+		}
+	}
+	os.Exit(exitCode)
+}
+
+// Exit status for scripts; set to 1 when a non-interactive run fails
+var exitCode = 0
+
+// Reports an error. Quiet runs send it to stderr so that stdout only ever has the result
+func fail(a ...interface{}) {
+	exitCode = 1
+	if *quietFlag {
+		fmt.Fprintln(os.Stderr, a...)
+		return
+	}
+	fmt.Println(a...)
+}
+
+// Checks whether the dates will be asked for, as opposed to given with -start and -end or -date
+func isInteractive() bool {
+	return *startFlag == "" && *dateFlag == "" && !*sinceLastFlag
+}
+
+// A single fee transaction found in a file
+type Transaction struct {
+	File     string //Name of the file the transaction came from
+	Line     int
+	Date     time.Time
+	Desc     string
+	Amount   float64
+	Keyword  string //The fee word that matched the description
+	Reversal bool   //The fee was a reversal, so Amount has been made negative
+	Currency string //From the currency column, if the file has one
+	Hash     string //SHA-256 of the file, with -hash
+	Extra    string //From the -extra-col column, if the file has it
+}
+
+// Records which file the result and each of its transactions came from
+func (res *Result) setFile(currFile string) {
+	res.File = currFile
+	res.Hash = inputHash(currFile)
+	for i := range res.Transactions {
+		res.Transactions[i].File = filepath.Base(currFile)
+		res.Transactions[i].Hash = res.Hash
+	}
+}
+
+// Keeps Smallest and Largest up to date with a new fee; on a tie the earlier fee is kept
+// Reversals aren't fees in their own right, so they're left out
+func (res *Result) trackExtremes(trx Transaction) {
+	if trx.Reversal {
+		return
+	}
+	if res.Largest.Keyword == "" || trx.Amount < res.Smallest.Amount {
+		res.Smallest = trx
+	}
+	if res.Largest.Keyword == "" || trx.Amount > res.Largest.Amount {
+		res.Largest = trx
+	}
+}
+
+// A running total and the number of transactions in it
+type Subtotal struct {
+	Total float64
+	Count int
+}
+
+// Returns the subtotal with amount added as count more transactions
+func (s Subtotal) add(amount float64, count int) Subtotal {
+	return Subtotal{Total: s.Total + amount, Count: s.Count + count}
+}
+
+// The total for one of the config's report profiles
+type ReportTotal struct {
+	Name  string
+	Total float64
+	Count int
+}
+
+// The outcome of running the fee calculation over one file
+type Result struct {
+	File             string
+	Hash             string                        //SHA-256 of the file, with -hash
+	Lines            int                           //Number of lines processed
+	Total            float64                       //Total of fee transactions found
+	ByKeyword        map[string]float64            //Subtotal for each fee word
+	ByKeywordCount   map[string]int                //Number of fees for each fee word
+	ByMonth          map[string]float64            //Subtotal for each month, keyed yyyy-mm
+	ByMonthKeyword   map[string]map[string]float64 //Subtotal for each fee word within each month, for -pivot
+	ByCurrency       map[string]float64            //Subtotal for each currency, with "" for fees that don't have one
+	ByDay            map[string]Subtotal           //Subtotal and count for each day, keyed yyyy-mm-dd
+	ByWeekday        [7]Subtotal                   //Subtotal and count for each day of the week, indexed by time.Weekday
+	ByCounterparty   map[string]Subtotal           //Subtotal and count for each counterparty; only filled in with -group-by-counterparty
+	Reversals        int                           //Number of fees that were reversals and subtracted
+	Duplicates       int                           //Number of fees left out by -dedup because an earlier file had them
+	Smallest         Transaction                   //The smallest fee that isn't a reversal; only set if there is one
+	Largest          Transaction                   //The largest fee
+	Interest         float64                       //Total of interest transactions found
+	InterestCount    int                           //Number of interest transactions in Interest
+	ByInterest       map[string]float64            //Subtotal for each interest word
+	NonFeeTotal      float64                       //Total of debits in range that aren't fees; only counted with -non-fees or -pct-debits
+	NonFeeCount      int                           //Number of non-fee debits in NonFeeTotal
+	Suggestions      map[string]Suggestion         //Descriptions that look like fees but matched no word, with -suggest
+	Reports          []ReportTotal                 //Totals for each report profile in the config, in the same order
+	WeekendSkipped   int                           //Number of lines in range left out by -weekdays-only
+	CreditsIgnored   int                           //Number of fee word matches left out by -debits-only because they were credits
+	Debits           float64                       //Total of the debits in range, for -opening-balance
+	Credits          float64                       //Total of the credits in range, for -opening-balance
+	ReviewAccepted   int                           //Ambiguous matches confirmed as fees with -review
+	ReviewRejected   int                           //Ambiguous matches turned down with -review
+	Partial          bool                          //Processing stopped early because of -limit
+	Skipped          int                           //Number of lines skipped because they were missing fields or had a bad date or amount
+	SkippedBy        map[string]int                //The skipped lines counted by the kind of problem, for -stats
+	HeadersSkipped   int                           //Number of rows repeating the header, from statements pasted one after another
+	InRange          int                           //Number of lines with a date in the range
+	Days             int                           //Number of days in the date range, for -principal
+	Elapsed          time.Duration                 //How long the calculation took, for -stats
+	SubtotalsChecked int                           //Number of subtotal sections checked with -verify-subtotals
+	SubtotalErrors   int                           //Number of those sections that didn't add up
+	Warnings         []string                      //Problems found along the way, printed after processing
+	Transactions     []Transaction
+	Err              error //Set if the file could not be read or processed
+}
+
+// Runs one pass over a file's rows with dates from the user, returning -1 if they want to go again with new dates
+func process(currFile string, header []string, data [][]string) int {
+	//Ask user for dates, or pick up from the last run
+	var date1, date2 time.Time
+	var err error
+	if *sinceLastFlag {
+		date1, date2, err = sinceLastRange(currFile, header, data)
+	} else {
+		date1, date2, err = rangeDates()
+	}
+	if err != nil {
+		fail(err)
+		return 0
+	}
+	if *sinceLastFlag && date2.Before(date1) {
+		//Quiet runs keep stdout for the result alone
+		var w io.Writer = os.Stdout
+		if *quietFlag {
+			w = os.Stderr
+		}
+		fmt.Fprintln(w, "Nothing new since the last run, which processed up to", date1.AddDate(0, 0, -1).Format(dateEntry)+".")
+		return 0
+	}
+	if *showConfigFlag {
+		showSettings(date1, date2)
+	}
+	if !*quietFlag {
+		printRange(date1, date2)
+		if refPattern != nil {
+			fmt.Println(msg("refOnly"), *refFlag)
+		}
+	}
+
+	res, err := calculate(header, data, date1, date2, !*quietFlag)
+	if err != nil {
+		//The progress counter is still on its line
+		if !*quietFlag && res.Lines > 0 {
+			fmt.Println()
+		}
+		fail(err)
+		end()
+		return 0
+	}
+	res.setFile(currFile)
+	if previousPass != nil && !*quietFlag {
+		writeMatchDiff(os.Stdout, previousPass, res.Transactions)
+	}
+	previousPass = res.Transactions
+	saveSQLite([]Result{res})
+	saveExport(res.Transactions)
+	saveSplitExport(res.Transactions)
+	saveFixedWidth(res.Transactions)
+	savePivot(res)
+	out := newOutputWriter(os.Stdout, outputInfo{Name: filepath.Base(currFile), Start: date1, End: date2})
+	writeOutput(out, res)
+	copyTotal(res.Total)
+	checkBaseline(res.Total)
+	if *sinceLastFlag {
+		if err := saveWatermark(currFile, date2); err != nil {
+			fail("Could not save where this run got to:", err)
+		}
+	}
+
+	//The second range runs over the same rows, so there's no need to read the file again
+	if *compareRangeFlag != "" {
+		other, err := calculate(header, data, compareDate1, compareDate2, false)
+		if err != nil {
+			fail("Could not calculate the comparison range:", err)
+		} else {
+			//Quiet runs keep stdout for the result alone, which may be JSON or a bare number
+			var w io.Writer = os.Stdout
+			if *quietFlag {
+				w = os.Stderr
+			}
+			writeComparison(w, res, other, date1, date2)
+		}
+	}
+
+	//Dates given on the command line mean there's no one to ask about continuing
+	if !isInteractive() || *noLoopFlag {
+		return 0
+	}
+	fmt.Print(msg("continue"))
+	var key string
+	fmt.Scanln(&key)
+	switch key {
+	case "c":
+		fmt.Println("=============================")
+		fmt.Println()
+		reloadWordLists()
+		return -1
+	default:
+		return 0
+	}
+}
+
+// Writes the totals for the main range and the -compare-range side by side, with the difference between them
+func writeComparison(w io.Writer, res Result, other Result, date1 time.Time, date2 time.Time) {
+	fmt.Fprintln(w, "=============================")
+	fmt.Fprintln(w, date1.Format("02 Jan 2006"), "to", date2.Format("02 Jan 2006")+":", formatAmount(res.Total))
+	fmt.Fprintln(w, compareDate1.Format("02 Jan 2006"), "to", compareDate2.Format("02 Jan 2006")+":", formatAmount(other.Total))
+
+	delta := res.Total - other.Total
+	change := ""
+	if !isZero(other.Total) {
+		change = " (" + formatPercent(delta/other.Total*100) + "%)"
+	}
+	fmt.Fprintln(w, "DIFFERENCE:", formatAmount(delta)+change)
+	fmt.Fprintln(w)
+}
+
+// Processes several files over the same date range and prints a combined report
+// The files are processed concurrently, but the report is always in the order the files were given
+func processMulti(files []string) {
+	if !*quietFlag {
+		fmt.Println("Processing", len(files), "files.")
+	}
+
+	//Ask user for dates once for all the files
+	date1, date2, err := rangeDates()
+	if err != nil {
+		fail(err)
+		return
+	}
+	if *showConfigFlag {
+		showSettings(date1, date2)
+	}
+	if !*quietFlag {
+		printRange(date1, date2)
+		if refPattern != nil {
+			fmt.Println(msg("refOnly"), *refFlag)
+		}
+	}
+
+	started := time.Now()
+	results := calculateFiles(files, date1, date2)
+	if *dedupFlag {
+		dedupFiles(results)
+	}
+	saveSQLite(results)
+
+	combined, failed := combineResults(results)
+	combined.Elapsed = time.Since(started) //The files run at the same time, so this is less than their times added up
+	//Totals that leave out a file are not complete, which is what -strict is there to prevent
+	if *strictFlag && failed > 0 {
+		for _, res := range results {
+			if res.Err != nil {
+				fail(filepath.Base(res.File)+":", res.Err)
+			}
+		}
+		return
+	}
+	//The other files' totals are still shown, but scripts should know the batch wasn't complete
+	if failed > 0 {
+		exitCode = 1
+	}
+	saveExport(combined.Transactions)
+	saveSplitExport(combined.Transactions)
+	saveFixedWidth(combined.Transactions)
+	savePivot(combined)
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = filepath.Base(file)
+	}
+	out := newOutputWriter(os.Stdout, outputInfo{Name: strings.Join(names, ", "), Start: date1, End: date2, Results: results})
+	writeOutput(out, combined)
+	copyTotal(combined.Total)
+	checkBaseline(combined.Total)
+}
+
+// Adds up the results of several files into one, skipping any that failed
+// Returns the combined result and the number of files that failed
+func combineResults(results []Result) (Result, int) {
+	combined := Result{
+		ByKeyword:      make(map[string]float64),
+		ByKeywordCount: make(map[string]int),
+		ByMonth:        make(map[string]float64),
+		ByCurrency:     make(map[string]float64),
+		ByDay:          make(map[string]Subtotal),
+		ByInterest:     make(map[string]float64),
+		ByCounterparty: make(map[string]Subtotal),
+		ByMonthKeyword: make(map[string]map[string]float64),
+		Suggestions:    make(map[string]Suggestion),
+		SkippedBy:      make(map[string]int),
+		Reports:        make([]ReportTotal, len(config.Reports)),
+	}
+	for i, profile := range config.Reports {
+		combined.Reports[i].Name = profile.Name
+	}
+
+	failed := 0
+	for _, res := range results {
+		if res.Err != nil {
+			failed += 1
+			continue
+		}
+		combined.Lines += res.Lines
+		combined.Total += res.Total
+		combined.Reversals += res.Reversals
+		combined.Duplicates += res.Duplicates
+		if res.Largest.Keyword != "" {
+			combined.trackExtremes(res.Smallest)
+			combined.trackExtremes(res.Largest)
+		}
+		combined.WeekendSkipped += res.WeekendSkipped
+		combined.CreditsIgnored += res.CreditsIgnored
+		combined.Debits += res.Debits
+		combined.Credits += res.Credits
+		combined.Skipped += res.Skipped
+		for kind, count := range res.SkippedBy {
+			combined.SkippedBy[kind] += count
+		}
+		combined.InRange += res.InRange
+		combined.HeadersSkipped += res.HeadersSkipped
+		//Every file covers the same range
+		combined.Days = res.Days
+		combined.SubtotalsChecked += res.SubtotalsChecked
+		combined.SubtotalErrors += res.SubtotalErrors
+		combined.NonFeeTotal += res.NonFeeTotal
+		combined.NonFeeCount += res.NonFeeCount
+		combined.Interest += res.Interest
+		combined.InterestCount += res.InterestCount
+		for word, subtotal := range res.ByInterest {
+			combined.ByInterest[word] += subtotal
+		}
+		combined.Transactions = append(combined.Transactions, res.Transactions...)
+		for i, report := range res.Reports {
+			combined.Reports[i].Total += report.Total
+			combined.Reports[i].Count += report.Count
+		}
+		for keyword, subtotal := range res.ByKeyword {
+			combined.ByKeyword[keyword] += subtotal
+		}
+		for keyword, count := range res.ByKeywordCount {
+			combined.ByKeywordCount[keyword] += count
+		}
+		for month, subtotal := range res.ByMonth {
+			combined.ByMonth[month] += subtotal
+		}
+		for desc, suggestion := range res.Suggestions {
+			suggestion.Count += combined.Suggestions[desc].Count
+			combined.Suggestions[desc] = suggestion
+		}
+		for month, byKeyword := range res.ByMonthKeyword {
+			for keyword, subtotal := range byKeyword {
+				addMonthKeyword(combined.ByMonthKeyword, month, keyword, subtotal)
+			}
+		}
+		for day, subtotal := range res.ByDay {
+			combined.ByDay[day] = combined.ByDay[day].add(subtotal.Total, subtotal.Count)
+		}
+		for day, subtotal := range res.ByWeekday {
+			combined.ByWeekday[day] = combined.ByWeekday[day].add(subtotal.Total, subtotal.Count)
+		}
+		for currency, subtotal := range res.ByCurrency {
+			combined.ByCurrency[currency] += subtotal
+		}
+		for counterparty, subtotal := range res.ByCounterparty {
+			combined.ByCounterparty[counterparty] = combined.ByCounterparty[counterparty].add(subtotal.Total, subtotal.Count)
+		}
+		if len(res.ByCurrency) == 0 {
+			combined.ByCurrency[""] += res.Total //Files without a currency column still need to be kept apart from those with one
+		}
+	}
+	return combined, failed
+}
+
+// Runs the calculation on each file using a pool of workers bounded by the number of CPUs
+// Each worker writes only to its own file's slot, so the results come back in the same order as files
+func calculateFiles(files []string, date1 time.Time, date2 time.Time) []Result {
+	results := make([]Result, len(files))
+	workers := runtime.NumCPU()
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = calculateFile(files[i], date1, date2)
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// Reads and calculates a single file without any progress output, for use in the multi-file mode
+// A panic is turned into the file's error so that one bad file can't bring down the rest of the batch
+func calculateFile(currFile string, date1 time.Time, date2 time.Time) (res Result) {
+	defer func() {
+		if r := recover(); r != nil {
+			res = Result{File: currFile, Err: fmt.Errorf("Unexpected error: %v", r)}
+		}
+	}()
+
+	header, data, err := readFile(currFile)
+	if err != nil {
+		return Result{File: currFile, Err: err}
+	}
+	res, err = calculate(header, data, date1, date2, false)
+	res.setFile(currFile)
+	res.Err = err
+	return res
+}
+
+// Reads the header row and the rest of the data from a .csv file
+func readFile(currFile string) ([]string, [][]string, error) {
+	if strings.EqualFold(inputExt(currFile), ".xlsx") {
+		return readSheet(currFile)
+	}
+
+	file, err := openInput(currFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	var input io.Reader = file
+	if *fixEOLFlag {
+		input = &eolReader{r: file}
+	}
+
+	//Throw away any preamble before the header. These are raw lines, so they don't need to be valid csv
+	buffered := bufio.NewReader(input)
+	for i := 0; i < *skipLinesFlag; i++ {
+		if _, err := buffered.ReadString('\n'); err != nil {
+			return nil, nil, errors.New("File appears to be empty.")
+		}
+	}
+
+	//Run the file through the reader
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1 //i.e. unspecified number of fields in case they change it
+
+	//Read the header row
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("File appears to be empty.")
+	} else if err != nil && !*detectHeaderFlag {
+		return nil, nil, err
+	}
+
+	//Look further down for the header if the preamble length varies. The preamble may not be valid csv, so quotes are relaxed until it's found
+	if *detectHeaderFlag {
+		reader.LazyQuotes = true
+		for i := 1; !isHeader(header); i++ {
+			if i == headerScanLines {
+				return nil, nil, errNoHeader()
+			}
+			header, err = reader.Read()
+			if err == io.EOF {
+				return nil, nil, errNoHeader()
+			}
+		}
+		reader.LazyQuotes = false
+	}
+
+	//Read the rest of the file, or with -limit just enough of it to know whether there is more
+	var data [][]string
+	for *limitFlag <= 0 || len(data) < *limitFlag+2 {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, errors.New("File read error. The file does not appear to be a *.csv file.")
+		}
+		data = append(data, record)
+	}
+
+	return header, data, nil
+}
+
+// Number of lines -detect-header looks through for the header row
+const headerScanLines = 20
+
+// Checks whether a row has all three of the date, description and value headers
+func isHeader(row []string) bool {
+	return getindex(row, *dateColFlag) >= 0 && getindex(row, *descColFlag) >= 0 && getindex(row, *amntColFlag) >= 0
+}
+
+func errNoHeader() error {
+	return fmt.Errorf("Could not find a header row with the columns \"%s\", \"%s\" and \"%s\" in the first %d lines.", *dateColFlag, *descColFlag, *amntColFlag, headerScanLines)
+}
+
+// Reads the header row and the rest of the data from the -sheet sheet of an .xlsx file
+func readSheet(currFile string) ([]string, [][]string, error) {
+	rows, err := readXLSX(currFile, *sheetFlag)
+	if err != nil {
+		return nil, nil, err
+	}
+	if *skipLinesFlag < len(rows) {
+		rows = rows[*skipLinesFlag:]
+	} else {
+		rows = nil
+	}
+	if len(rows) == 0 {
+		return nil, nil, errors.New("File appears to be empty.")
+	}
+	if *detectHeaderFlag {
+		found := false
+		for i := 0; i < len(rows) && i < headerScanLines; i++ {
+			if isHeader(rows[i]) {
+				rows = rows[i:]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, errNoHeader()
+		}
+	}
+
+	data := rows[1:]
+	if *limitFlag > 0 && len(data) > *limitFlag+2 {
+		data = data[:*limitFlag+2]
+	}
+	return rows[0], data, nil
+}
+
+// Totals the fee transactions in data that fall between date1 and date2 inclusive
+// showProgress prints the line counter as it goes; leave it off when several files are running at once
+func calculate(header []string, data [][]string, date1 time.Time, date2 time.Time, showProgress bool) (Result, error) {
+	res := Result{ByKeyword: make(map[string]float64), ByKeywordCount: make(map[string]int), ByMonth: make(map[string]float64), ByCurrency: make(map[string]float64), ByDay: make(map[string]Subtotal), ByInterest: make(map[string]float64), ByCounterparty: make(map[string]Subtotal), ByMonthKeyword: make(map[string]map[string]float64), Suggestions: make(map[string]Suggestion), SkippedBy: make(map[string]int)}
+	res.Days = spanDays(date1, date2)
+	started := time.Now()
+
+	if *combinedColFlag != "" {
+		var err error
+		header, data, err = splitCombinedColumn(header, data, *combinedColFlag)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	//Get the index of the columns we need from the header
+	colDate := getindex(header, *dateColFlag)
+	colDesc := getindex(header, *descColFlag)
+	colAmnt := getindex(header, *amntColFlag)
+	colRef := getindex(header, refField)
+	colCur := getindex(header, curField)
+	colCredit := -1
+	if *debitsOnlyFlag || *openingBalanceFlag != "" {
+		colCredit = getindex(header, *creditColFlag)
+	}
+	colExtra := -1
+	if *extraColFlag != "" {
+		colExtra = getindex(header, *extraColFlag)
+		if colExtra < 0 {
+			res.Warnings = append(res.Warnings, "The -extra-col \""+*extraColFlag+"\" was not found in the file, so it is left blank.")
+		}
+	}
+	for _, col := range []struct {
+		index int
+		name  string
+	}{{colDate, *dateColFlag}, {colDesc, *descColFlag}, {colAmnt, *amntColFlag}} {
+		if col.index < 0 {
+			return res, errors.New("The column \"" + col.name + "\" was not found in the file.")
+		}
+	}
+	if refPattern != nil && colRef < 0 {
+		return res, errors.New("The reference column \"" + refField + "\" was not found in the file.")
+	}
+
+	//Report profiles search their own column, which may not be in every file
+	res.Reports = make([]ReportTotal, len(config.Reports))
+	reportCols := make([]int, len(config.Reports))
+	for i, profile := range config.Reports {
+		res.Reports[i].Name = profile.Name
+		reportCols[i] = colDesc
+		if profile.Column != "" {
+			reportCols[i] = getindex(header, profile.Column)
+			if reportCols[i] < 0 {
+				res.Warnings = append(res.Warnings, "Report \""+profile.Name+"\" skipped: the column \""+profile.Column+"\" was not found in the file.")
+			}
+		}
+	}
+
+	//Number of fields a row needs to have all of the columns we use
+	need := maxIndex(colDate, colDesc, colAmnt) + 1
+	if refPattern != nil {
+		need = maxIndex(need-1, colRef) + 1
+	}
+
+	if len(data) == 0 {
+		return res, nil
+	}
+
+	layout, err := fileDateFormat(data[1:], colDate)
+	if err != nil {
+		return res, err
+	}
+
+	//A wrong amount column would otherwise only show up as an error on the first fee
+	amntHint := checkAmountColumn(header, data[1:], colAmnt)
+	if amntHint != "" {
+		res.Warnings = append(res.Warnings, amntHint)
+	}
+
+	if *warnRaggedFlag {
+		res.Warnings = append(res.Warnings, raggedRows(header, data[1:])...)
+	}
+
+	if *verifySubtotalsFlag {
+		var mismatches []string
+		res.SubtotalsChecked, mismatches = checkSubtotals(data[1:], colDesc, colAmnt)
+		res.SubtotalErrors = len(mismatches)
+		res.Warnings = append(res.Warnings, mismatches...)
+	}
+
+	//Number of lines that will be processed, for the percentage and ETA
+	totalLines := len(data) - 1
+	if *limitFlag > 0 && *limitFlag < totalLines {
+		totalLines = *limitFlag
+	}
+	status := newProgress(totalLines)
+	now := time.Now()
+
+	for _, currLine := range data[1:] {
+		if *limitFlag > 0 && res.Lines == *limitFlag {
+			res.Partial = true
+			break
+		}
+		res.Lines += 1
+		if showProgress {
+			switch verbose {
+			case true:
+				fmt.Printf("\n")
+				fmt.Print("Processing line " + strconv.Itoa(res.Lines) + "… ")
+			default:
+				status.show(res.Lines)
+			}
+		}
+
+		//Statements pasted one after another into one file repeat the header at the start of each one
+		if isHeaderRow(currLine, header) {
+			res.HeadersSkipped += 1
+			continue
+		}
+
+		//Rows can be shorter than the header since the field count isn't fixed
+		if len(currLine) < need {
+			if err := skipLine(&res, "too few fields", fmt.Sprintf("it has %d fields but %d are needed.", len(currLine), need)); err != nil {
+				return res, err
+			}
+			continue
+		}
+
+		//Subtotal rows repeat amounts that are already in the detail rows
+		if *verifySubtotalsFlag && isSubtotalRow(currLine[colDesc]) {
+			continue
+		}
+
+		currDate, err := parseDate(layout, currLine[colDate])
+		if err != nil {
+			if err := skipLine(&res, "bad date", fmt.Sprintf("the date %q is not in the format %s.", currLine[colDate], layout)); err != nil {
+				return res, err
+			}
+			continue
+		}
+		currDate = currDate.AddDate(0, 0, *dateShiftFlag)
+
+		inRange := currDate.Compare(date1) >= 0 && currDate.Compare(date2) <= 0
+		//The balance follows every movement in range, whatever -ref and -weekdays-only leave out of the fees
+		if inRange && *openingBalanceFlag != "" {
+			addFlow(&res, currLine, colAmnt, colCredit)
+		}
+
+		if refPattern != nil && !refPattern.MatchString(currLine[colRef]) {
+			continue
+		}
+
+		if inRange {
+			res.InRange += 1
+			if *weekdaysOnlyFlag && (currDate.Weekday() == time.Saturday || currDate.Weekday() == time.Sunday) {
+				res.WeekendSkipped += 1
+				continue
+			}
+			addReports(&res, currLine, reportCols, colAmnt)
+
+			currDesc := currLine[colDesc]
+
+			//Only the one description counts, so the fee and interest words don't come into it
+			exactDesc := strings.TrimSpace(*exactDescFlag)
+			if exactDesc != "" && strings.TrimSpace(currDesc) != exactDesc {
+				continue
+			}
 
-func main() {
+			//Interest is its own category, kept out of the fee total
+			if interestWord := matchWord(currDesc, interestList); interestWord != "" && exactDesc == "" {
+				currAmnt, _, err := parseFeeAmount(&res, currLine[colAmnt])
+				if err != nil {
+					if err := skipLine(&res, "bad or blank amount", err.Error()); err != nil {
+						return res, withHint(err, amntHint)
+					}
+					continue
+				}
+				if isReversal(currDesc) {
+					currAmnt = -currAmnt
+					res.Reversals += 1
+				}
+				res.Interest += currAmnt
+				res.InterestCount += 1
+				res.ByInterest[interestWord] += currAmnt
+				continue
+			}
 
-	writeHeader()
+			keyword := matchFee(currDesc)
+			if exactDesc != "" {
+				keyword = exactDesc
+			}
+			if keyword == "" && feeAmounts != nil {
+				keyword = matchFeeAmount(currLine[colAmnt])
+			}
+			//Reviewing needs the keyboard, so it can only happen in the interactive single file mode
+			if keyword != "" && *reviewFlag && showProgress && containsAny(keyword, ambiguousList) {
+				if confirmFee(currLine) {
+					res.ReviewAccepted += 1
+				} else {
+					res.ReviewRejected += 1
+					keyword = ""
+				}
+			}
+			//The same description can turn up on a credit, like a refund, which isn't a fee
+			if keyword != "" && *debitsOnlyFlag && !isDebit(currLine, colAmnt, colCredit) {
+				res.CreditsIgnored += 1
+				continue
+			}
+			//Fees for the other words are left out altogether, rather than counted as non-fee debits
+			if keyword != "" && *onlyKeywordTotalFlag && *onlyKeywordFlag != "" && keyword != *onlyKeywordFlag {
+				continue
+			}
+			if keyword != "" {
+				currAmnt, amntCur, err := parseFeeAmount(&res, currLine[colAmnt])
+				if err != nil {
+					if err := skipLine(&res, "bad or blank amount", err.Error()); err != nil {
+						return res, withHint(err, amntHint)
+					}
+					continue
+				}
+				reversal := isReversal(currDesc)
+				if reversal {
+					currAmnt = -currAmnt
+					res.Reversals += 1
+				}
+				//A fee dated after today usually means the date format is wrong, e.g. the day and month read the wrong way round
+				if currDate.After(now) {
+					res.Warnings = append(res.Warnings, fmt.Sprintf("Line %d is dated %s, which is in the future; check that the dates really are in the format %s.", res.Lines, currDate.Format(dateEntry), layout))
+				}
+				if showProgress && verbose {
+					fmt.Print(formatAmount(currAmnt))
+				}
+				res.Total += currAmnt
+				res.ByKeyword[keyword] += currAmnt
+				res.ByKeywordCount[keyword] += 1
+				res.ByMonth[currDate.Format("2006-01")] += currAmnt
+				addMonthKeyword(res.ByMonthKeyword, currDate.Format("2006-01"), keyword, currAmnt)
+				res.ByDay[currDate.Format(dateEntry)] = res.ByDay[currDate.Format(dateEntry)].add(currAmnt, 1)
+				res.ByWeekday[currDate.Weekday()] = res.ByWeekday[currDate.Weekday()].add(currAmnt, 1)
+				if *counterpartyFlag {
+					party := counterparty(currDesc)
+					res.ByCounterparty[party] = res.ByCounterparty[party].add(currAmnt, 1)
+				}
+				//The currency column wins; otherwise a currency written after the amount is used
+				currCur := amntCur
+				if colCur >= 0 && colCur < len(currLine) {
+					currCur = strings.TrimSpace(currLine[colCur])
+				}
+				res.ByCurrency[currCur] += currAmnt
+				trx := Transaction{Line: res.Lines, Date: currDate, Desc: currDesc, Amount: currAmnt, Keyword: keyword, Reversal: reversal, Currency: currCur}
+				if colExtra >= 0 && colExtra < len(currLine) {
+					trx.Extra = strings.TrimSpace(currLine[colExtra])
+				}
+				res.Transactions = append(res.Transactions, trx)
+				res.trackExtremes(trx)
+			} else if (*nonFeesFlag || *pctDebitsFlag) && strings.TrimSpace(currLine[colAmnt]) != "" {
+				//Credits leave the Debit cell empty, so those are passed over
+				currAmnt, _, err := parseAmount(currLine[colAmnt])
+				if err != nil {
+					res.Warnings = append(res.Warnings, fmt.Sprintf("Line %d was left out of the non-fee total: cannot process the amount.", res.Lines))
+					continue
+				}
+				res.NonFeeTotal += currAmnt
+				res.NonFeeCount += 1
+			}
+			if keyword == "" && *suggestFlag {
+				if reason := suggestReason(currDesc); reason != "" {
+					res.Suggestions[currDesc] = Suggestion{Count: res.Suggestions[currDesc].Count + 1, Reason: reason}
+				}
+			}
 
-	//Get args from the os (i.e. Windows drag and drop)
-	args := os.Args[1:]
-	argct := len(args)
+		}
+	}
+	if showProgress {
+		switch verbose {
+		case true:
+			fmt.Printf("\n")
+		case false:
+			status.clear()
+		}
+	}
 
-	//Check number of args received to make sure we received exactly one file.
-	//Ideally no args would open a file open ui, but there's nothing in the standard library and we're trying to avoid going outside that
-	//Could add option to process multiple files, but would probably be confusing anyway
-	switch {
-	case argct < 1:
-		fmt.Println("This program is designed for drag-and-drop. Please drag the .csv file onto the program.")
-		end()
-	case argct > 1:
-		fmt.Println("This program can only handle one file at a time.")
-		end()
+	res.Elapsed = time.Since(started)
+	return res, nil
+}
+
+// Number of amount cells looked at to decide whether a column is numeric
+const amountSamples = 20
+
+// Checks that the amount column mostly holds numbers, and if not suggests the columns that do
+// Returns "" when the column looks fine
+func checkAmountColumn(header []string, data [][]string, colAmnt int) string {
+	if looksNumeric(data, colAmnt) {
+		return ""
 	}
 
-	for _, currFile := range args {
-		// fileCount = fileCount + 1
-		// fileCountStr := strconv.Itoa(fileCount)
-		// fmt.Println("Processing " + filepath.Base(currFile) + " (" + fileCountStr + " of " + numFilesStr + ")…")
-		i := -1
-		for i != 0 {
-			i = process(currFile)
+	var candidates []string
+	for col, name := range header {
+		if col != colAmnt && looksNumeric(data, col) {
+			candidates = append(candidates, "'"+name+"'")
 		}
 	}
+	warning := "The configured amount column '" + header[colAmnt] + "' doesn't look numeric"
+	switch len(candidates) {
+	case 0:
+		return warning + ", and neither does any other column."
+	case 1:
+		return warning + " — did you mean column " + candidates[0] + "?"
+	default:
+		return warning + " — did you mean one of columns " + strings.Join(candidates, ", ") + "?"
+	}
 }
 
-func process(currFile string) int {
-	file, err := os.Open(currFile)
-	if err != nil {
-		panic(err)
+// Checks whether most of the first non-blank cells in a column are numbers
+// Blank cells don't count either way, since debits are blank on credit rows
+func looksNumeric(data [][]string, col int) bool {
+	numeric, sampled := 0, 0
+	for _, row := range data {
+		if sampled == amountSamples {
+			break
+		}
+		if col >= len(row) || strings.TrimSpace(row[col]) == "" {
+			continue
+		}
+		sampled += 1
+		if _, _, err := parseAmount(row[col]); err == nil {
+			numeric += 1
+		}
 	}
-	defer file.Close()
+	return sampled == 0 || numeric*2 > sampled
+}
 
-	//Run the file through the reader
-	reader := csv.NewReader(file)
-	reader.FieldsPerRecord = -1 //i.e. unspecified number of fields in case they change it
+// Adds a hint to an error's message, if there is one
+func withHint(err error, hint string) error {
+	if hint == "" {
+		return err
+	}
+	return fmt.Errorf("%w\n%s", err, hint)
+}
 
-	//Read the header row
-	header, err := reader.Read()
-	if err == io.EOF {
-		log.Println("File appears to be empty.")
-	} else if err != nil {
-		panic(err)
+// Deals with a line that can't be used: normally it's skipped with a warning, but with -strict it stops the run
+// kind is the sort of problem, which -stats counts the skipped lines by
+func skipLine(res *Result, kind string, reason string) error {
+	if *strictFlag {
+		return fmt.Errorf("Line %d cannot be processed: %s", res.Lines, reason)
 	}
+	res.Skipped += 1
+	res.SkippedBy[kind] += 1
+	res.Warnings = append(res.Warnings, fmt.Sprintf("Skipped line %d: %s", res.Lines, reason))
+	if *maxSkipsFlag > 0 && res.Skipped > *maxSkipsFlag {
+		return fmt.Errorf("Too many lines skipped, more than the -max-skips of %d (the last was line %d), so the file is too damaged to trust the total.", *maxSkipsFlag, res.Lines)
+	}
+	return nil
+}
 
-	//Get the index of the columns we need from the header
-	colDate := getindex(header, dateField)
-	colDesc := getindex(header, descField)
-	colAmnt := getindex(header, amntField)
+// Parses an amount cell, which may have a currency written after the number, e.g. "123.45 HTG"
+// Returns the amount and the currency, which is "" if there wasn't one
+func parseAmount(cell string) (float64, string, error) {
+	cell = strings.TrimSpace(cell)
+	number := strings.TrimRightFunc(cell, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.'
+	})
+	currency := strings.TrimSpace(cell[len(number):])
+	amount, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	return amount, currency, err
+}
 
-	//Read the rest of the file
-	data, err := reader.ReadAll()
-	if err != nil {
-		fmt.Println("File read error. The file does not appear to be a *.csv file.")
-		end()
+// Checks that a row is a debit: a positive amount, and an empty or zero credit cell if there's a credit column
+func isDebit(row []string, colAmnt int, colCredit int) bool {
+	amount, _, err := parseAmount(row[colAmnt])
+	if err != nil || amount <= 0 || isZero(amount) {
+		return false
 	}
+	if colCredit >= 0 && colCredit < len(row) && strings.TrimSpace(row[colCredit]) != "" {
+		credit, _, err := parseAmount(row[colCredit])
+		return err == nil && isZero(credit)
+	}
+	return true
+}
 
-	//Ask user for dates
-	date1, date2 := getDates()
-	fmt.Println("Processing transactions from", date1.Format("02 Jan 2006"), "to", date2.Format("02 Jan 2006"))
+// Parses the amount of a fee on the current line, and the currency after it if there is one
+// A negative fee usually means the row is misclassified, so it's flagged; -abs instead just counts it as positive
+func parseFeeAmount(res *Result, cell string) (float64, string, error) {
+	if strings.TrimSpace(cell) == "" {
+		if *blankAsZeroFlag {
+			return 0, "", nil
+		}
+		return 0, "", errors.New("the amount is blank; use -blank-as-zero to count it as 0.00.")
+	}
+	amount, currency, err := parseAmount(cell)
+	if err != nil {
+		return 0, "", fmt.Errorf("cannot process the amount %q.", cell)
+	}
+	if amount < 0 {
+		if *absFlag {
+			return -amount, currency, nil
+		}
+		res.Warnings = append(res.Warnings, fmt.Sprintf("Line %d has a negative fee (%s); check whether it is classified correctly.", res.Lines, cell))
+	}
+	return amount, currency, nil
+}
 
-	var runningTotal float64 = 0 //Total of fee transactions found
-	currLnNo := 0                //Current line being processed
-	for _, currLine := range data[1:] {
-		currLnNo += 1
-		switch verbose {
-		case true:
-			fmt.Printf("\n")
-			fmt.Print("Processing line " + strconv.Itoa(currLnNo) + "… ")
-		default:
-			fmt.Printf("\r")
-			fmt.Printf("Processing line " + strconv.Itoa(currLnNo) + "…")
+// Shows the whole row and asks the user whether it should count as a fee
+func confirmFee(currLine []string) bool {
+	fmt.Println()
+	fmt.Println(strings.Join(currLine, " | "))
+	for {
+		fmt.Print(msg("confirmFee"))
+		var key string
+		fmt.Scanln(&key)
+		switch strings.ToLower(key) {
+		case "y", "o":
+			return true
+		case "n":
+			return false
 		}
+	}
+}
 
-		currDate, err := time.Parse(dateFormat, currLine[colDate])
+// Adds the row's amount to every report profile whose keywords appear in its column
+func addReports(res *Result, currLine []string, reportCols []int, colAmnt int) {
+	for i, profile := range config.Reports {
+		col := reportCols[i]
+		if col < 0 || col >= len(currLine) || !containsAny(currLine[col], profile.Keywords) {
+			continue
+		}
+		if strings.TrimSpace(currLine[colAmnt]) == "" {
+			continue
+		}
+		currAmnt, _, err := parseAmount(currLine[colAmnt])
 		if err != nil {
-			log.Println(err)
-			panic(err)
+			res.Warnings = append(res.Warnings, fmt.Sprintf("Line %d was left out of report \"%s\": cannot process the amount.", res.Lines, profile.Name))
+			continue
 		}
+		res.Reports[i].Total += currAmnt
+		res.Reports[i].Count += 1
+	}
+}
 
-		if currDate.Compare(date1) >= 0 && currDate.Compare(date2) <= 0 {
-			currDesc := currLine[colDesc]
-			if containsFee(currDesc) {
-				currAmnt, err := strconv.ParseFloat(currLine[colAmnt], 64)
-				if err != nil {
-					log.Println("Cannot process the amount on line", currLnNo)
-					panic(err)
-				}
-				switch verbose {
-				case true:
-					fmt.Print(strconv.FormatFloat(currAmnt, 'f', 2, 64))
-				}
-				runningTotal += currAmnt
+// Label for fees whose description has no counterparty
+const unknownCounterparty = "(unknown)"
+
+// Gets the counterparty from a description like "FRAIS VIREMENT - JOHN DOE": whatever follows the last -counterparty-sep
+func counterparty(desc string) string {
+	i := strings.LastIndex(desc, *counterpartySepFlag)
+	if *counterpartySepFlag == "" || i < 0 {
+		return unknownCounterparty
+	}
+	party := strings.TrimSpace(desc[i+len(*counterpartySepFlag):])
+	if party == "" {
+		return unknownCounterparty
+	}
+	return party
+}
+
+// Checks if the text contains any of the words
+func containsAny(text string, words []string) bool {
+	for _, word := range words {
+		if containsWord(text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Prints the report profile totals, one labeled line each
+func printReports(w io.Writer, reports []ReportTotal) {
+	for _, report := range reports {
+		fmt.Fprintln(w, report.Name+":", formatAmount(report.Total), "("+strconv.Itoa(report.Count), "transactions)")
+	}
+}
+
+// Saves the results to the -sqlite database, if one was given, and reports how it went
+func saveSQLite(results []Result) {
+	if *sqliteFlag == "" {
+		return
+	}
+	if err := writeSQLite(*sqliteFlag, results); err != nil {
+		fail("Could not save to the SQLite database:", err)
+		return
+	}
+	if !*quietFlag {
+		fmt.Println("Saved matched transactions to", *sqliteFlag)
+	}
+}
+
+// The single status line shown while a file is processed, with the percentage done and an estimate of the time left
+type progress struct {
+	total    int       //Number of lines to process
+	lastTime time.Time //When the rate was last worked out
+	lastLine int       //Line number at lastTime
+	rate     float64   //Lines per second, smoothed so the ETA doesn't jump around
+	width    int       //Length of the last status printed, so it can be blanked out
+}
+
+// How often the rate is recalculated
+const progressInterval = 500 * time.Millisecond
+
+func newProgress(total int) *progress {
+	return &progress{total: total, lastTime: time.Now()}
+}
+
+// Prints the status for the current line over the previous one
+func (p *progress) show(line int) {
+	if now := time.Now(); now.Sub(p.lastTime) >= progressInterval {
+		current := float64(line-p.lastLine) / now.Sub(p.lastTime).Seconds()
+		if p.rate == 0 {
+			p.rate = current
+		} else {
+			p.rate = 0.7*p.rate + 0.3*current
+		}
+		p.lastTime = now
+		p.lastLine = line
+	}
+
+	text := "Processing line " + strconv.Itoa(line) + "…"
+	if p.total > 0 {
+		text += " " + strconv.Itoa(line*100/p.total) + "%"
+		if p.rate > 0 {
+			eta := time.Duration(float64(p.total-line) / p.rate * float64(time.Second))
+			text += " — ETA " + eta.Round(time.Second).String()
+		}
+	}
+	padding := ""
+	if n := utf8.RuneCountInString(text); n < p.width {
+		padding = strings.Repeat(" ", p.width-n)
+	} else {
+		p.width = n
+	}
+	fmt.Print("\r" + text + padding)
+}
+
+// Blanks out the status line so the summary can be printed in its place
+func (p *progress) clear() {
+	fmt.Print("\r" + strings.Repeat(" ", p.width) + "\r")
+}
+
+// Writes the -pivot file, if one was given, and reports how it went
+func savePivot(res Result) {
+	if *pivotFlag == "" {
+		return
+	}
+	if err := writePivot(*pivotFlag, res.ByMonthKeyword); err != nil {
+		fail("Could not write the pivot file:", err)
+		return
+	}
+	if !*quietFlag {
+		fmt.Println("Wrote the fees by month and fee word to", *pivotFlag)
+	}
+}
+
+// Adds an amount to a month's subtotal for a fee word, making the month's map if it's the first
+func addMonthKeyword(byMonthKeyword map[string]map[string]float64, month string, keyword string, amount float64) {
+	if byMonthKeyword[month] == nil {
+		byMonthKeyword[month] = make(map[string]float64)
+	}
+	byMonthKeyword[month][keyword] += amount
+}
+
+// Writes the fees to the -export file, if one was given, and reports how it went
+func saveExport(transactions []Transaction) {
+	if *exportFlag == "" {
+		return
+	}
+	transactions = listedFees(transactions)
+	if err := writeExport(*exportFlag, transactions); err != nil {
+		fail("Could not write the export file:", err)
+		return
+	}
+	if !*quietFlag {
+		fmt.Println("Exported", len(transactions), "fees to", *exportFlag)
+	}
+}
+
+// Writes the -fixed-out file, if asked for
+func saveFixedWidth(transactions []Transaction) {
+	if *fixedOutFlag == "" {
+		return
+	}
+	transactions = listedFees(transactions)
+	if err := writeFixedWidth(*fixedOutFlag, transactions, fixedWidths); err != nil {
+		fail("Could not write the fixed-width file:", err)
+		return
+	}
+	if !*quietFlag {
+		fmt.Println("Exported", len(transactions), "fees to", *fixedOutFlag)
+	}
+}
+
+// Writes the -split-export files, if asked for
+func saveSplitExport(transactions []Transaction) {
+	if *splitExportFlag == "" {
+		return
+	}
+	files, err := writeSplitExport(*splitExportFlag, listedFees(transactions))
+	if err != nil {
+		fail("Could not write the split export files:", err)
+		return
+	}
+	if !*quietFlag {
+		fmt.Println("Exported", len(files), "fee word files to", *splitExportFlag)
+	}
+}
+
+// Expands any folders in args into the .csv and .xlsx files they contain, sorted by name
+// Plain file arguments are passed through unchanged
+func expandArgs(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil || !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		var matches []string
+		for _, pattern := range []string{"*.csv", "*.xlsx"} {
+			found, err := filepath.Glob(filepath.Join(arg, pattern))
+			if err != nil {
+				return nil, err
 			}
+			matches = append(matches, found...)
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// Compiles the -ref pattern. Anything that isn't a valid regular expression is matched as plain text
+func compileRef(pattern string) *regexp.Regexp {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return regexp.MustCompile(regexp.QuoteMeta(pattern))
+	}
+	return re
+}
 
+// Checks if an amount is zero, allowing for floating point residue
+func isZero(amount float64) bool {
+	return math.Abs(amount) < *epsilonFlag
+}
+
+// Checks if two amounts are the same, allowing for floating point residue
+func nearlyEqual(a float64, b float64) bool {
+	return isZero(a - b)
+}
+
+// Returns the largest of the given column indexes
+func maxIndex(indexes ...int) int {
+	largest := -1
+	for _, index := range indexes {
+		if index > largest {
+			largest = index
 		}
 	}
-	switch verbose {
-	case true:
-		fmt.Printf("\n")
-	case false:
-		fmt.Printf("\r")
+	return largest
+}
+
+// Prints any warnings collected while processing a file
+func printWarnings(w io.Writer, res Result) {
+	for _, warning := range res.Warnings {
+		fmt.Fprintln(w, warning)
 	}
-	fmt.Println("Processed ", currLnNo, "lines")
-	fmt.Println("=============================")
-	fmt.Println("TOTAL:", strconv.FormatFloat(runningTotal, 'f', 2, 64))
-	fmt.Println()
+	if res.Skipped > 0 {
+		fmt.Fprintln(w, "Lines skipped:", res.Skipped)
+	}
+}
 
-	fmt.Print("Enter [c] to continue with new dates or enter any other key to exit: ")
-	var key string
-	fmt.Scanln(&key)
-	switch key {
-	case "c":
-		fmt.Println("=============================")
-		fmt.Println()
-		return -1
-	default:
-		return 0
+// Returns the keys of a map in sorted order so reports print the same way every time
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Splits -cols into the date, description and value headers
+// It's read like a csv row, so a header with a comma in it can be given in quotes
+func parseCols(value string) ([]string, error) {
+	reader := csv.NewReader(strings.NewReader(value))
+	reader.TrimLeadingSpace = true
+	cols, err := reader.Read()
+	if err != nil || len(cols) != 3 {
+		return nil, errors.New("-cols needs exactly three header names separated by commas: date, description and value. Put a header with a comma in it in double quotes.")
+	}
+	for i := range cols {
+		cols[i] = strings.TrimSpace(cols[i])
 	}
+	return cols, nil
 }
 
 // Gets the index for a string (i.e. for the header row)
+// An exact match is preferred, but differences in case and spacing are allowed
 func getindex(row []string, seek string) int {
 	for index, value := range row {
 		if value == seek {
 			return index
 		}
 	}
+	for index, value := range row {
+		if normalizeHeader(value) == normalizeHeader(seek) {
+			return index
+		}
+	}
 	return -1
 }
 
+// Lowercases a header and tidies its spacing, including any byte order mark left at the start of the file
+// Commas count as spaces and stray quotes are dropped, so "Date Trx" also finds a header written "Date, Trx"
+func normalizeHeader(header string) string {
+	header = strings.TrimPrefix(header, "\ufeff")
+	header = strings.NewReplacer(",", " ", "\"", "").Replace(header)
+	return strings.ToLower(strings.Join(strings.Fields(header), " "))
+}
+
+// Checks whether a row is a copy of the header, ignoring case and spacing
+func isHeaderRow(row []string, header []string) bool {
+	if len(row) != len(header) {
+		return false
+	}
+	for i := range row {
+		if normalizeHeader(row[i]) != normalizeHeader(header[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 // Checks if the current slice contains a string inidcating a fee
 func containsFee(desc string) bool {
-	for _, value := range feeList {
-		if strings.Contains(desc, value) {
+	return matchFee(desc) != ""
+}
+
+// Checks if the description contains a word marking the fee as a reversal
+func isReversal(desc string) bool {
+	return containsAny(desc, reversalList)
+}
+
+// Returns the first fee word found in the description, or "" if there isn't one
+// A description on the include list matches with the included text standing in for the fee word
+func matchFee(desc string) string {
+	if keyword := matchWord(desc, feeList); keyword != "" {
+		return keyword
+	}
+	return matchWord(desc, includeList)
+}
+
+// Returns the first of the words found in the description, or "" if there isn't one
+func matchWord(desc string, words []string) string {
+	for _, value := range words {
+		if containsWord(desc, value) {
+			return value
+		}
+	}
+	return ""
+}
+
+// Checks if the text contains the word. With -whole-word it must not be part of a longer word,
+// e.g. "taxes" doesn't match "syntaxes"; otherwise any substring counts.
+// With -normalize both are normalized first, so the word lists don't need every spelling.
+func containsWord(text string, word string) bool {
+	if *normalizeFlag {
+		text = normalizeText(text)
+		word = normalizeText(word)
+	}
+	if !*wholeWordFlag {
+		return strings.Contains(text, word)
+	}
+	if word == "" {
+		return false
+	}
+
+	//Check each place the word turns up, since an early one might be inside a longer word
+	for start := 0; start < len(text); {
+		i := strings.Index(text[start:], word)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(word)
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if (i == 0 || !isWordRune(before)) && (end == len(text) || !isWordRune(after)) {
 			return true
 		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		start = i + size
 	}
 	return false
 }
 
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
 func end() {
-	fmt.Println("Press any key to exit")
+	//Only needed to keep the window open after drag-and-drop
+	if !isInteractive() || *noLoopFlag {
+		return
+	}
+	fmt.Println(msg("exit"))
 	fmt.Scanln()
 }
 
@@ -195,28 +1842,26 @@ func end() {
 func getDates() (time.Time, time.Time) {
 
 	//Ask for beginning date
-	fmt.Println("Enter the beginning and ending dates to process using the format yyyy-mm-dd.")
-	date1 := checkDate("Beginning Date: ")
+	fmt.Println(msg("dateIntro"))
+	date1 := checkDate(msg("beginPrompt"))
 
 	//Figure out default end dates, then ask.
-	mDate := time.Date(date1.Year(), date1.Month()+1, 0, 0, 0, 0, 0, date1.Location()) //Last day of the month; i.e. 00 Feb == 31 Jan, etc.
-	var qDate time.Time
-	switch {
-	case date1.Day() <= 15:
-		qDate = time.Date(date1.Year(), date1.Month(), 15, 0, 0, 0, 0, date1.Location())
-	case date1.Day() >= 16:
-		qDate = mDate
-	}
-	fmt.Println("Enter the ending date. You can also enter 'q' to calculate to the end of the quinzaine or 'm' to calculate to the end of the month.")
+	qDate, mDate := endDates(date1)
+	fmt.Println(msg("endIntro"))
 
 	//Was supposed to use checkDate, but
 	i := -1
 	var usrDate string
 	var date2 time.Time
 	for i != 0 {
-		fmt.Print("Ending date: ")
+		fmt.Print(msg("endPrompt"))
+		usrDate = ""
 		fmt.Scanln(&usrDate)
 		switch usrDate {
+		case "":
+			//Just pressing enter means only the beginning date
+			date2 = date1
+			i = 0
 		case "q":
 			date2 = qDate
 			i = 0
@@ -224,12 +1869,16 @@ func getDates() (time.Time, time.Time) {
 			date2 = mDate
 			i = 0
 		default:
-			rtDate, err := time.Parse(dateEntry, usrDate)
-			switch err != nil {
-			case true:
-				fmt.Println("Entered date is invalid, please try again.")
+			rtDate, err := time.ParseInLocation(dateEntry, usrDate, location)
+			switch {
+			case err != nil:
+				fmt.Println(msg("badDate"))
 				i = -1
-			case false:
+			case rtDate.Before(date1):
+				//Usually the wrong year on a range that crosses into a new one
+				fmt.Println(msg("endBeforeBegin"))
+				i = -1
+			default:
 				date2 = rtDate
 				i = 0
 			}
@@ -239,6 +1888,92 @@ func getDates() (time.Time, time.Time) {
 	return date1, date2
 }
 
+// Returns the date range from -start and -end, or asks for it if -start wasn't given
+// With -max-span-days, a range that's too long is refused, or has to be confirmed when the dates are typed in
+func rangeDates() (time.Time, time.Time, error) {
+	if isInteractive() {
+		for {
+			date1, date2 := getDates()
+			if *maxSpanFlag <= 0 || spanDays(date1, date2) <= *maxSpanFlag {
+				return date1, date2, nil
+			}
+			fmt.Printf(msg("spanConfirm"), spanDays(date1, date2))
+			var answer string
+			fmt.Scanln(&answer)
+			if answer = strings.ToLower(answer); answer == "y" || answer == "o" {
+				return date1, date2, nil
+			}
+		}
+	}
+
+	var date1, date2 time.Time
+	var err error
+	if *dateFlag != "" {
+		date1, date2, err = parseRange(*dateFlag, *dateFlag)
+	} else {
+		date1, date2, err = parseRange(*startFlag, *endFlag)
+	}
+	if err == nil && *maxSpanFlag > 0 && spanDays(date1, date2) > *maxSpanFlag {
+		err = fmt.Errorf("The range %s to %s is %d days, which is more than -max-span-days %d.", date1.Format(dateEntry), date2.Format(dateEntry), spanDays(date1, date2), *maxSpanFlag)
+	}
+	return date1, date2, err
+}
+
+// Number of days in a range, counting both ends
+// Rounded, since a day can be 23 or 25 hours long when the clocks change
+func spanDays(date1 time.Time, date2 time.Time) int {
+	return int(math.Round(date2.Sub(date1).Hours()/24)) + 1
+}
+
+// Prints the date range about to be processed, making it clear when it's a single day
+func printRange(date1 time.Time, date2 time.Time) {
+	if date1.Equal(date2) {
+		fmt.Println(msg("processingDay"), date1.Format("02 Jan 2006"), msg("oneDay"))
+		return
+	}
+	fmt.Println(msg("processing"), date1.Format("02 Jan 2006"), msg("to"), date2.Format("02 Jan 2006"))
+}
+
+// Parses a beginning date and an ending date, which can also be 'q' or 'm' as at the prompt
+func parseRange(start string, finish string) (time.Time, time.Time, error) {
+	date1, err := time.ParseInLocation(dateEntry, start, location)
+	if err != nil {
+		return date1, date1, errors.New("The beginning date \"" + start + "\" is invalid; use the format yyyy-mm-dd.")
+	}
+
+	qDate, mDate := endDates(date1)
+	switch finish {
+	case "q":
+		return date1, qDate, nil
+	case "m":
+		return date1, mDate, nil
+	}
+	date2, err := time.ParseInLocation(dateEntry, finish, location)
+	if err != nil {
+		return date1, date2, errors.New("The ending date \"" + finish + "\" is invalid; use the format yyyy-mm-dd, 'q' or 'm'.")
+	}
+	if date2.Before(date1) {
+		return date1, date2, errors.New("The ending date " + finish + " is before the beginning date " + start + "; check the year if the range crosses into a new one.")
+	}
+	return date1, date2, nil
+}
+
+// Returns the end of the quinzaine and the end of the month for a beginning date, for the 'q' and 'm' shortcuts
+// time.Date normalizes month 13 to January of the next year, so December's month end is still 31 Dec of the same year.
+// Dates are all parsed at midnight in the one -tz location, so comparing them against the range is midnight to midnight
+// and daylight saving can't move a day across the boundaries; spanDays rounds, so a 23 or 25 hour day still counts as one.
+func endDates(date1 time.Time) (time.Time, time.Time) {
+	mDate := time.Date(date1.Year(), date1.Month()+1, 0, 0, 0, 0, 0, date1.Location()) //Last day of the month; i.e. 00 Feb == 31 Jan, etc.
+	var qDate time.Time
+	switch {
+	case date1.Day() <= 15:
+		qDate = time.Date(date1.Year(), date1.Month(), 15, 0, 0, 0, 0, date1.Location())
+	case date1.Day() >= 16:
+		qDate = mDate
+	}
+	return qDate, mDate
+}
+
 // Asks the user to enter a date using the supplied prompt and returns it as a time.Time object
 // If there is an entry error, it will reprompt the user to reenter it until a valid date is entered.
 func checkDate(prompt string) time.Time {
@@ -247,10 +1982,10 @@ func checkDate(prompt string) time.Time {
 	for i != 0 {
 		fmt.Print(prompt)
 		fmt.Scanln(&usrDate)
-		rtDate, err := time.Parse(dateEntry, usrDate)
+		rtDate, err := time.ParseInLocation(dateEntry, usrDate, location)
 		switch err != nil {
 		case true:
-			fmt.Println("Entered date is invalid, please try again.")
+			fmt.Println(msg("badDate"))
 			i = -1
 		case false:
 			return rtDate
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Builds a header and data the way readFile returns them, from comma separated lines.
+// The first data row is the statement's opening line, which calculate passes over, so one is added here.
+func testFile(header string, rows ...string) ([]string, [][]string) {
+	data := [][]string{strings.Split("01-Jul-23,SOLDE,,", ",")}
+	for _, row := range rows {
+		data = append(data, strings.Split(row, ","))
+	}
+	return strings.Split(header, ","), data
+}
+
+func testDate(t *testing.T, value string) time.Time {
+	t.Helper()
+	date, err := time.ParseInLocation(dateEntry, value, location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return date
+}
+
+func TestCalculateSkipsShortRow(t *testing.T) {
+	header, data := testFile("Date Trx,Description,Debit,Credit",
+		"03-Jul-23,frais SMS,10.00,",
+		"15-Jul-23,frais",
+		"20-Jul-23,taxes,3.25,",
+	)
+	res, err := calculate(header, data, testDate(t, "2023-07-01"), testDate(t, "2023-07-31"), false)
+	if err != nil {
+		t.Fatalf("calculate failed on a short row: %v", err)
+	}
+	if res.Skipped != 1 || res.SkippedBy["too few fields"] != 1 {
+		t.Errorf("Skipped = %d, SkippedBy = %v; want the one short row", res.Skipped, res.SkippedBy)
+	}
+	found := false
+	for _, warning := range res.Warnings {
+		if strings.HasPrefix(warning, "Skipped line 2:") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Warnings = %q; want one for line 2", res.Warnings)
+	}
+	if !isZero(res.Total - 13.25) {
+		t.Errorf("Total = %v; want 13.25 from the other rows", res.Total)
+	}
+}
+
+func TestEndDatesDecember(t *testing.T) {
+	qDate, mDate := endDates(testDate(t, "2023-12-20"))
+	want := testDate(t, "2023-12-31")
+	if !qDate.Equal(want) || !mDate.Equal(want) {
+		t.Errorf("endDates(2023-12-20) = %s, %s; want 31 Dec for both", qDate.Format(dateEntry), mDate.Format(dateEntry))
+	}
+	qDate, mDate = endDates(testDate(t, "2023-12-01"))
+	if qDate.Format(dateEntry) != "2023-12-15" || !mDate.Equal(want) {
+		t.Errorf("endDates(2023-12-01) = %s, %s; want 15 Dec and 31 Dec", qDate.Format(dateEntry), mDate.Format(dateEntry))
+	}
+}
+
+func TestParseRangeAcrossYears(t *testing.T) {
+	date1, date2, err := parseRange("2023-12-20", "2024-01-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if date1.Format(dateEntry) != "2023-12-20" || date2.Format(dateEntry) != "2024-01-10" {
+		t.Errorf("parseRange = %s to %s", date1.Format(dateEntry), date2.Format(dateEntry))
+	}
+
+	header, data := testFile("Date Trx,Description,Debit,Credit",
+		"19-Dec-23,frais SMS,1.00,",
+		"28-Dec-23,frais SMS,10.00,",
+		"05-Jan-24,frais SMS,20.00,",
+		"11-Jan-24,frais SMS,100.00,",
+	)
+	res, err := calculate(header, data, date1, date2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isZero(res.Total - 30) {
+		t.Errorf("Total = %v; want 30.00 from the fees on both sides of the new year", res.Total)
+	}
+
+	if _, date2, err := parseRange("2023-12-20", "m"); err != nil || date2.Format(dateEntry) != "2023-12-31" {
+		t.Errorf("parseRange(2023-12-20, m) = %s, %v; want 2023-12-31", date2.Format(dateEntry), err)
+	}
+}
+
+func TestParseRangeEndBeforeStart(t *testing.T) {
+	if _, _, err := parseRange("2024-01-10", "2023-12-20"); err == nil {
+		t.Error("parseRange accepted an ending date before the beginning date")
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		cell     string
+		amount   float64
+		currency string
+	}{
+		{"123.45 HTG", 123.45, "HTG"},
+		{"123.45 USD", 123.45, "USD"},
+		{"123.45", 123.45, ""},
+		{" 10.00 ", 10, ""},
+	}
+	for _, test := range tests {
+		amount, currency, err := parseAmount(test.cell)
+		if err != nil || !isZero(amount-test.amount) || currency != test.currency {
+			t.Errorf("parseAmount(%q) = %v, %q, %v; want %v, %q", test.cell, amount, currency, err, test.amount, test.currency)
+		}
+	}
+	for _, cell := range []string{"HTG", "", "n/a"} {
+		if _, _, err := parseAmount(cell); err == nil {
+			t.Errorf("parseAmount(%q) didn't fail without a number", cell)
+		}
+	}
+}
+
+func TestDateShiftMovesRange(t *testing.T) {
+	defer func(saved int) { *dateShiftFlag = saved }(*dateShiftFlag)
+	*dateShiftFlag = 1
+
+	header, data := testFile("Date Trx,Description,Debit,Credit",
+		"30-Jun-23,frais SMS,1.00,", //Shifted to 1 Jul, into the range
+		"15-Jul-23,taxes,10.00,",
+		"31-Jul-23,frais SMS,100.00,", //Shifted to 1 Aug, out of it
+	)
+	res, err := calculate(header, data, testDate(t, "2023-07-01"), testDate(t, "2023-07-31"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isZero(res.Total - 11) {
+		t.Errorf("Total = %v; want 11.00 with the dates shifted a day later", res.Total)
+	}
+	if len(res.Transactions) > 0 && res.Transactions[0].Date.Format(dateEntry) != "2023-07-01" {
+		t.Errorf("first fee dated %s; want 2023-07-01", res.Transactions[0].Date.Format(dateEntry))
+	}
+}
+
+func TestGetindexCommaHeader(t *testing.T) {
+	header := []string{"\"Date, Trx\"", "Description", "Debit"}
+	for _, seek := range []string{"Date, Trx", "Date Trx", "date trx"} {
+		if index := getindex(header, seek); index != 0 {
+			t.Errorf("getindex(%q) = %d; want 0", seek, index)
+		}
+	}
+	if index := getindex([]string{"Date, Trx", "Description"}, "Date Trx"); index != 0 {
+		t.Errorf("getindex(Date Trx) on \"Date, Trx\" = %d; want 0", index)
+	}
+}
+
+func TestParseCols(t *testing.T) {
+	cols, err := parseCols(`"Date, Trx",Description,Debit`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Date, Trx", "Description", "Debit"}; strings.Join(cols, "|") != strings.Join(want, "|") {
+		t.Errorf("parseCols = %q; want %q", cols, want)
+	}
+	for _, value := range []string{"Date,Description", `"Date, Trx,Description,Debit`, "a,b,c,d"} {
+		if _, err := parseCols(value); err == nil {
+			t.Errorf("parseCols(%q) didn't fail", value)
+		}
+	}
+}
+
+func TestMaxSkips(t *testing.T) {
+	defer func(saved int) { *maxSkipsFlag = saved }(*maxSkipsFlag)
+	header, data := testFile("Date Trx,Description,Debit,Credit",
+		"03-Jul-23,frais SMS,,",
+		"04-Jul-23,frais",
+		"05-Jul-23,taxes,3.25,",
+	)
+	date1, date2 := testDate(t, "2023-07-01"), testDate(t, "2023-07-31")
+
+	*maxSkipsFlag = 2
+	if _, err := calculate(header, data, date1, date2, false); err != nil {
+		t.Errorf("calculate stopped at 2 skipped lines with -max-skips 2: %v", err)
+	}
+	*maxSkipsFlag = 1
+	res, err := calculate(header, data, date1, date2, false)
+	if err == nil {
+		t.Fatal("calculate carried on past -max-skips 1")
+	}
+	if res.Lines != 2 || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("stopped after %d lines with %q; want it to stop at line 2", res.Lines, err)
+	}
+}
+
+func TestOpeningBalanceCountsEveryMovement(t *testing.T) {
+	defer func(saved string, weekdays bool) {
+		*openingBalanceFlag, *weekdaysOnlyFlag = saved, weekdays
+	}(*openingBalanceFlag, *weekdaysOnlyFlag)
+	*openingBalanceFlag = "1000"
+	*weekdaysOnlyFlag = true
+
+	header, data := testFile("Date Trx,Description,Debit,Credit",
+		"03-Jul-23,frais SMS,10.00,",
+		"08-Jul-23,RETRAIT ATM,100.00,", //A Saturday
+		"09-Jul-23,DEPOT,,50.00,",       //A Sunday
+		"10-Jul-23,taxes,3.00,",
+	)
+	res, err := calculate(header, data, testDate(t, "2023-07-01"), testDate(t, "2023-07-31"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isZero(res.Debits-113) || !isZero(res.Credits-50) {
+		t.Errorf("Debits = %v, Credits = %v; want 113.00 and 50.00 with the weekend lines in", res.Debits, res.Credits)
+	}
+	if res.WeekendSkipped != 2 || !isZero(res.Total-13) {
+		t.Errorf("WeekendSkipped = %d, Total = %v; want the weekend lines still left out of the fees", res.WeekendSkipped, res.Total)
+	}
+}
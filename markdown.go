@@ -0,0 +1,88 @@
+package main
+
+// Markdown report for pasting into issues or wikis, with -format markdown
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Writes the summary and a table of the fees found
+// In the multi-file mode the table has a column with each fee's file
+type markdownOutput struct {
+	w       io.Writer
+	info    outputInfo
+	started bool          //The heading has been written
+	inTable bool          //The table header has been written
+	pending []Transaction //Fees held back to be merged with -collapse
+}
+
+func (o *markdownOutput) writeHeading() {
+	if o.started {
+		return
+	}
+	o.started = true
+	fmt.Fprintln(o.w, "## Fees:", markdownEscape(o.info.Name))
+	fmt.Fprintln(o.w)
+	fmt.Fprintln(o.w, "**Period:**", o.info.Start.Format("02 Jan 2006"), "to", o.info.End.Format("02 Jan 2006"))
+	fmt.Fprintln(o.w)
+}
+
+func (o *markdownOutput) WriteTransaction(trx Transaction) {
+	if *collapseFlag || *collapseAllFlag {
+		o.pending = append(o.pending, trx)
+		return
+	}
+	o.writeRow(collapsedFee{First: trx, Last: trx, Count: 1})
+}
+
+// Writes a table row, adding the table header before the first one
+func (o *markdownOutput) writeRow(fee collapsedFee) {
+	o.writeHeading()
+	showFile := o.info.Results != nil
+	if !o.inTable {
+		o.inTable = true
+		if showFile {
+			fmt.Fprintln(o.w, "| File | Date | Description | Fee word | Amount |")
+			fmt.Fprintln(o.w, "|---|---|---|---|---:|")
+		} else {
+			fmt.Fprintln(o.w, "| Date | Description | Fee word | Amount |")
+			fmt.Fprintln(o.w, "|---|---|---|---:|")
+		}
+	}
+	trx := fee.First
+	date := trx.Date.Format(dateEntry)
+	desc := markdownEscape(trx.Desc)
+	if fee.Count > 1 {
+		if !fee.Last.Date.Equal(trx.Date) {
+			date += " – " + fee.Last.Date.Format(dateEntry)
+		}
+		desc = strconv.Itoa(fee.Count) + "× " + desc + " @ " + formatAmount(trx.Amount)
+	}
+	cells := []string{date, desc, markdownEscape(trx.Keyword), formatAmount(fee.Total())}
+	if showFile {
+		cells = append([]string{markdownEscape(trx.File)}, cells...)
+	}
+	fmt.Fprintln(o.w, "| "+strings.Join(cells, " | ")+" |")
+}
+
+func (o *markdownOutput) WriteSummary(res Result) {
+	for _, fee := range collapseFees(o.pending, *collapseAllFlag) {
+		o.writeRow(fee)
+	}
+	o.writeHeading()
+	if o.inTable {
+		fmt.Fprintln(o.w)
+	}
+	fmt.Fprintf(o.w, "**Total: %s** (%d fees, %d lines processed)\n", formatAmount(res.Total), len(res.Transactions), res.Lines)
+	if res.InterestCount > 0 {
+		fmt.Fprintf(o.w, "\n**Interest: %s** (%d transactions)\n", formatAmount(res.Interest), res.InterestCount)
+	}
+}
+
+// Stops pipes in descriptions from breaking the table
+func markdownEscape(text string) string {
+	return strings.ReplaceAll(text, "|", "\\|")
+}
@@ -0,0 +1,59 @@
+package main
+
+// Text shown to the user at the prompts, in each language -lang can pick
+// "en" is the original wording and is used for anything missing from another language
+
+var messages = map[string]map[string]string{
+	"en": {
+		"dateIntro":      "Enter the beginning and ending dates to process using the format yyyy-mm-dd.",
+		"beginPrompt":    "Beginning Date: ",
+		"endIntro":       "Enter the ending date. You can also enter 'q' to calculate to the end of the quinzaine or 'm' to calculate to the end of the month, or just press enter for the beginning date only.",
+		"endPrompt":      "Ending date: ",
+		"badDate":        "Entered date is invalid, please try again.",
+		"endBeforeBegin": "The ending date is before the beginning date, please try again.",
+		"spanConfirm":    "This range is %d days, more than -max-span-days. Process it anyway? (y/n) ",
+		"processing":     "Processing transactions from",
+		"processingDay":  "Processing transactions on",
+		"oneDay":         "(one day)",
+		"to":             "to",
+		"refOnly":        "Only including references matching",
+		"continue":       "Enter [c] to continue with new dates or enter any other key to exit: ",
+		"exit":           "Press any key to exit",
+		"dragDrop":       "This program is designed for drag-and-drop. Please drag the .csv file onto the program.",
+		"confirmFee":     "Count this as a fee? [y/n]: ",
+	},
+	"fr": {
+		"dateIntro":      "Entrez les dates de début et de fin à traiter au format aaaa-mm-jj.",
+		"beginPrompt":    "Date de début : ",
+		"endIntro":       "Entrez la date de fin. Vous pouvez aussi entrer 'q' pour calculer jusqu'à la fin de la quinzaine ou 'm' jusqu'à la fin du mois, ou simplement appuyer sur Entrée pour la date de début seulement.",
+		"endPrompt":      "Date de fin : ",
+		"badDate":        "La date entrée n'est pas valide, veuillez réessayer.",
+		"endBeforeBegin": "La date de fin est avant la date de début, veuillez réessayer.",
+		"spanConfirm":    "Cette période fait %d jours, plus que -max-span-days. La traiter quand même ? (o/n) ",
+		"processing":     "Traitement des transactions du",
+		"processingDay":  "Traitement des transactions du",
+		"oneDay":         "(un seul jour)",
+		"to":             "au",
+		"refOnly":        "Seulement les références correspondant à",
+		"continue":       "Entrez [c] pour continuer avec de nouvelles dates ou une autre touche pour quitter : ",
+		"exit":           "Appuyez sur une touche pour quitter",
+		"dragDrop":       "Ce programme fonctionne par glisser-déposer. Veuillez glisser le fichier .csv sur le programme.",
+		"confirmFee":     "Compter ceci comme frais ? [o/n] : ",
+	},
+}
+
+// Returns the message for key in the -lang language
+func msg(key string) string {
+	if text, ok := messages[*langFlag][key]; ok {
+		return text
+	}
+	return messages["en"][key]
+}
+
+// Language for spelling out the total with -words: -lang if it was given, otherwise wordsLocale
+func wordsLang() string {
+	if *langFlag == "" {
+		return wordsLocale
+	}
+	return *langFlag
+}
@@ -0,0 +1,39 @@
+package main
+
+// Loose matching for -normalize: accents, punctuation and extra spaces are taken out of descriptions and fee words
+// before they're compared, so "frâis" and "frais." both match "frais".
+// The standard library has no Unicode normalization, so accents are stripped with a table covering the Latin letters banks use.
+
+import (
+	"strings"
+	"unicode"
+)
+
+var accentReplacer = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a",
+	"À", "A", "Á", "A", "Â", "A", "Ã", "A", "Ä", "A", "Å", "A",
+	"ç", "c", "Ç", "C",
+	"è", "e", "é", "e", "ê", "e", "ë", "e",
+	"È", "E", "É", "E", "Ê", "E", "Ë", "E",
+	"ì", "i", "í", "i", "î", "i", "ï", "i",
+	"Ì", "I", "Í", "I", "Î", "I", "Ï", "I",
+	"ñ", "n", "Ñ", "N",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o",
+	"Ò", "O", "Ó", "O", "Ô", "O", "Õ", "O", "Ö", "O",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u",
+	"Ù", "U", "Ú", "U", "Û", "U", "Ü", "U",
+	"ý", "y", "ÿ", "y", "Ý", "Y",
+	"œ", "oe", "Œ", "OE", "æ", "ae", "Æ", "AE",
+)
+
+// Strips accents, turns punctuation into spaces and collapses runs of whitespace into one space
+func normalizeText(text string) string {
+	text = accentReplacer.Replace(text)
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return ' '
+		}
+		return r
+	}, text)
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,800 @@
+package main
+
+// Output formats for the results, picked with -format
+// Each format is an OutputWriter, so adding a format is one new type and a case in newOutputWriter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The values -format accepts
+var outputFormats = []string{"text", "json", "ndjson", "csv", "tsv", "markdown", "logfmt", "csv-summary"}
+
+// Receives the fees found in a run, one at a time and in order, and then the summary once at the end
+type OutputWriter interface {
+	WriteTransaction(trx Transaction)
+	WriteSummary(res Result)
+}
+
+// What the writers need to know about the run besides the result itself
+type outputInfo struct {
+	Name    string //Name of the file, or the names of all the files in the multi-file mode
+	Start   time.Time
+	End     time.Time
+	Results []Result //Each file's own result in the multi-file mode; nil for a single file
+}
+
+// Checks that format is one of outputFormats
+func validFormat(format string) bool {
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Picks the writer for -format. A -template replaces the format, and -quiet cuts the text format down to the result
+func newOutputWriter(w io.Writer, info outputInfo) OutputWriter {
+	if summaryTemplate != nil {
+		return templateOutput{w: w, info: info}
+	}
+	switch *formatFlag {
+	case "json":
+		return &jsonOutput{w: w, info: info, transactions: []jsonTransaction{}}
+	case "ndjson":
+		return ndjsonOutput{encoder: json.NewEncoder(w), info: info}
+	case "csv":
+		return newCSVOutput(w, ',')
+	case "tsv":
+		return newCSVOutput(w, '\t')
+	case "markdown":
+		return &markdownOutput{w: w, info: info}
+	case "logfmt":
+		return logfmtOutput{w: w, info: info}
+	case "csv-summary":
+		return csvSummaryOutput{w: w, info: info}
+	}
+	if *quietFlag {
+		return quietOutput{w: w, info: info}
+	}
+	return textOutput{w: w, info: info}
+}
+
+// Sends each fee to list and then the summary to out
+func writeOutput(out OutputWriter, res Result) {
+	for _, trx := range listedFees(res.Transactions) {
+		out.WriteTransaction(trx)
+	}
+	out.WriteSummary(res)
+}
+
+// Returns the fees to list: all of them, or with -only-keyword just the ones that fee word matched
+func listedFees(transactions []Transaction) []Transaction {
+	if *onlyKeywordFlag == "" {
+		return transactions
+	}
+	var listed []Transaction
+	for _, trx := range transactions {
+		if trx.Keyword == *onlyKeywordFlag {
+			listed = append(listed, trx)
+		}
+	}
+	return listed
+}
+
+// The usual report for people: counts, warnings and totals, without listing the fees
+type textOutput struct {
+	w    io.Writer
+	info outputInfo
+}
+
+func (o textOutput) WriteTransaction(trx Transaction) {}
+
+func (o textOutput) WriteSummary(res Result) {
+	if o.info.Results != nil {
+		writeMultiSummary(o.w, o.info.Results, res)
+		return
+	}
+	writeSummary(o.w, res)
+}
+
+// Only the result, for scripts
+type quietOutput struct {
+	w    io.Writer
+	info outputInfo
+}
+
+func (o quietOutput) WriteTransaction(trx Transaction) {}
+
+func (o quietOutput) WriteSummary(res Result) {
+	for _, fileRes := range o.info.Results {
+		if fileRes.Err != nil {
+			fail(filepath.Base(fileRes.File)+":", fileRes.Err)
+		}
+	}
+	writeQuiet(o.w, res)
+}
+
+// The summary filled into -template
+type templateOutput struct {
+	w    io.Writer
+	info outputInfo
+}
+
+func (o templateOutput) WriteTransaction(trx Transaction) {}
+
+func (o templateOutput) WriteSummary(res Result) {
+	writeTemplate(o.w, o.info.Name, res, o.info.Start, o.info.End)
+}
+
+// One row per fee, with a header row; the same layout as -export
+type csvOutput struct {
+	writer       *csv.Writer
+	started      bool
+	runningTotal float64
+}
+
+func newCSVOutput(w io.Writer, comma rune) *csvOutput {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	return &csvOutput{writer: writer}
+}
+
+func (o *csvOutput) writeHeader() {
+	if o.started {
+		return
+	}
+	o.started = true
+	header := []string{"File", "Date", "Description", "Keyword", "Amount"}
+	if *cumulativeFlag {
+		header = append(header, "Running total")
+	}
+	if *hashFlag {
+		header = append(header, "SHA-256")
+	}
+	if *extraColFlag != "" {
+		header = append(header, *extraColFlag)
+	}
+	o.writer.Write(header)
+}
+
+func (o *csvOutput) WriteTransaction(trx Transaction) {
+	o.writeHeader()
+	o.runningTotal += trx.Amount
+	row := []string{trx.File, trx.Date.Format(dateEntry), trx.Desc, trx.Keyword, machineAmount(trx.Amount)}
+	if *cumulativeFlag {
+		row = append(row, machineAmount(o.runningTotal))
+	}
+	if *hashFlag {
+		row = append(row, trx.Hash)
+	}
+	if *extraColFlag != "" {
+		row = append(row, trx.Extra)
+	}
+	o.writer.Write(row)
+}
+
+// The rows are the whole output, so this just makes sure the header is there and everything is written
+func (o *csvOutput) WriteSummary(res Result) {
+	o.writeHeader()
+	o.writer.Flush()
+}
+
+// An amount that is written to JSON with two decimals, so totals don't come out as 28.750000000000004
+type jsonAmount float64
+
+func (a jsonAmount) MarshalJSON() ([]byte, error) {
+	return []byte(machineAmount(float64(a))), nil
+}
+
+type jsonTransaction struct {
+	File        string     `json:"file"`
+	Line        int        `json:"line"`
+	Date        string     `json:"date"`
+	Description string     `json:"description"`
+	Keyword     string     `json:"keyword"`
+	Amount      jsonAmount `json:"amount"`
+	Currency    string     `json:"currency,omitempty"`
+	Reversal    bool       `json:"reversal,omitempty"`
+	Extra       string     `json:"extra,omitempty"` //The -extra-col value
+}
+
+// One file's outcome in the multi-file mode
+type jsonFile struct {
+	File  string     `json:"file"`
+	Lines int        `json:"lines"`
+	Total jsonAmount `json:"total"`
+	Error string     `json:"error,omitempty"`
+	Hash  string     `json:"sha256,omitempty"`
+}
+
+type jsonSummary struct {
+	File           string                `json:"file"`
+	Hash           string                `json:"sha256,omitempty"`
+	Start          string                `json:"start"`
+	End            string                `json:"end"`
+	Lines          int                   `json:"lines"`
+	Total          jsonAmount            `json:"total"`
+	Count          int                   `json:"count"`
+	ByCurrency     map[string]jsonAmount `json:"byCurrency,omitempty"`
+	ByKeyword      map[string]jsonAmount `json:"byKeyword"`
+	ByCounterparty map[string]jsonAmount `json:"byCounterparty,omitempty"`
+	Interest       jsonAmount            `json:"interest"`
+	Warnings       []string              `json:"warnings,omitempty"`
+	Files          []jsonFile            `json:"files,omitempty"`
+	Transactions   []jsonTransaction     `json:"transactions"`
+}
+
+// A single JSON object with the summary and the fees, written once everything is in
+type jsonOutput struct {
+	w            io.Writer
+	info         outputInfo
+	transactions []jsonTransaction
+}
+
+func (o *jsonOutput) WriteTransaction(trx Transaction) {
+	o.transactions = append(o.transactions, newJSONTransaction(trx))
+}
+
+func (o *jsonOutput) WriteSummary(res Result) {
+	summary := newJSONSummary(o.info, res)
+	summary.Transactions = o.transactions
+
+	encoder := json.NewEncoder(o.w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(summary); err != nil {
+		fail("Could not write the JSON output:", err)
+	}
+}
+
+func newJSONTransaction(trx Transaction) jsonTransaction {
+	return jsonTransaction{
+		File:        trx.File,
+		Line:        trx.Line,
+		Date:        trx.Date.Format(dateEntry),
+		Description: trx.Desc,
+		Keyword:     trx.Keyword,
+		Amount:      jsonAmount(trx.Amount),
+		Currency:    trx.Currency,
+		Reversal:    trx.Reversal,
+		Extra:       trx.Extra,
+	}
+}
+
+// Fills in everything in the JSON summary except the transactions
+func newJSONSummary(info outputInfo, res Result) jsonSummary {
+	summary := jsonSummary{
+		File:      info.Name,
+		Hash:      res.Hash,
+		Start:     info.Start.Format(dateEntry),
+		End:       info.End.Format(dateEntry),
+		Lines:     res.Lines,
+		Total:     jsonAmount(res.Total),
+		Count:     len(res.Transactions),
+		ByKeyword: jsonAmounts(res.ByKeyword),
+		Interest:  jsonAmount(res.Interest),
+		Warnings:  res.Warnings,
+	}
+	if len(res.ByCurrency) > 1 {
+		summary.ByCurrency = jsonAmounts(res.ByCurrency)
+	}
+	if *counterpartyFlag {
+		summary.ByCounterparty = make(map[string]jsonAmount)
+		for party, subtotal := range res.ByCounterparty {
+			summary.ByCounterparty[party] = jsonAmount(subtotal.Total)
+		}
+	}
+	for _, fileRes := range info.Results {
+		file := jsonFile{File: filepath.Base(fileRes.File), Lines: fileRes.Lines, Total: jsonAmount(fileRes.Total), Hash: fileRes.Hash}
+		if fileRes.Err != nil {
+			file.Error = fileRes.Err.Error()
+		}
+		summary.Files = append(summary.Files, file)
+	}
+	return summary
+}
+
+// Newline-delimited JSON: each fee on its own line, then a summary line. The lines are written once the whole file
+// has been read and totalled (and deduplicated in the multi-file mode), not while it's still being read.
+// Every line has a "type" of "transaction" or "summary" so a consumer can tell them apart
+type ndjsonOutput struct {
+	encoder *json.Encoder
+	info    outputInfo
+}
+
+type ndjsonTransaction struct {
+	Type string `json:"type"`
+	jsonTransaction
+}
+
+type ndjsonSummary struct {
+	Type string `json:"type"`
+	jsonSummary
+	Transactions []jsonTransaction `json:"transactions,omitempty"` //Hides the summary's list, since each fee already has its own line
+}
+
+func (o ndjsonOutput) WriteTransaction(trx Transaction) {
+	if err := o.encoder.Encode(ndjsonTransaction{Type: "transaction", jsonTransaction: newJSONTransaction(trx)}); err != nil {
+		fail("Could not write the JSON output:", err)
+	}
+}
+
+func (o ndjsonOutput) WriteSummary(res Result) {
+	if err := o.encoder.Encode(ndjsonSummary{Type: "summary", jsonSummary: newJSONSummary(o.info, res)}); err != nil {
+		fail("Could not write the JSON output:", err)
+	}
+}
+
+func jsonAmounts(amounts map[string]float64) map[string]jsonAmount {
+	converted := make(map[string]jsonAmount, len(amounts))
+	for key, amount := range amounts {
+		converted[key] = jsonAmount(amount)
+	}
+	return converted
+}
+
+// Writes the multi-file report: each file's total, then the subtotals and totals for all of them together
+func writeMultiSummary(w io.Writer, results []Result, combined Result) {
+	fmt.Fprintln(w, "=============================")
+	var failed []string
+	for _, res := range results {
+		if res.Err != nil {
+			fmt.Fprintln(w, filepath.Base(res.File)+":", "ERROR -", res.Err)
+			failed = append(failed, filepath.Base(res.File))
+			continue
+		}
+		fmt.Fprintln(w, filepath.Base(res.File)+":", formatAmount(res.Total), "("+strconv.Itoa(res.Lines), "lines)")
+		if res.Hash != "" {
+			fmt.Fprintln(w, "SHA-256:", res.Hash)
+		}
+		if res.Partial {
+			fmt.Fprintln(w, "Stopped after", *limitFlag, "lines because of -limit; this total is partial.")
+		}
+		printWarnings(w, res)
+	}
+	fmt.Fprintln(w, "=============================")
+	for _, keyword := range sortedKeys(combined.ByKeyword) {
+		fmt.Fprintln(w, keyword+":", formatAmount(combined.ByKeyword[keyword]))
+	}
+	for _, word := range sortedKeys(combined.ByInterest) {
+		fmt.Fprintln(w, word+" (interest):", formatAmount(combined.ByInterest[word]))
+	}
+	writeCounts(w, combined)
+	if len(failed) > 0 {
+		fmt.Fprintln(w, len(results)-len(failed), "of", len(results), "files were processed. Failed:", strings.Join(failed, ", "))
+	}
+	writeTotals(w, combined)
+	if *statsFlag {
+		writeStats(w, combined)
+	}
+}
+
+// Writes the end-of-run summary for one file: line count, warnings, report totals and the fee total
+func writeSummary(w io.Writer, res Result) {
+	fmt.Fprintln(w, "Processed ", res.Lines, "lines")
+	if res.Hash != "" {
+		fmt.Fprintln(w, "SHA-256:", res.Hash)
+	}
+	if res.Partial {
+		fmt.Fprintln(w, "Stopped after", *limitFlag, "lines because of -limit; these results are partial.")
+	}
+	printWarnings(w, res)
+	writeCounts(w, res)
+	fmt.Fprintln(w, "=============================")
+	writeTotals(w, res)
+	if *statsFlag {
+		writeStats(w, res)
+	}
+}
+
+// The fields available to -template
+type templateData struct {
+	File     string //Name of the file, or the names of all the files in the multi-file mode
+	Start    string //Beginning date, yyyy-mm-dd
+	End      string //Ending date, yyyy-mm-dd
+	Total    string //Fee total to two decimals
+	Count    int    //Number of fee transactions
+	Lines    int    //Number of lines processed
+	Interest string //Interest total to two decimals
+	Hash     string //SHA-256 of the file with -hash; empty in the multi-file mode
+	Fees     []templateFee
+}
+
+// The fields of each fee in .Fees, in the order they were found
+type templateFee struct {
+	Date    time.Time //Use e.g. {{.Date.Format "02/01/2006"}} to pick the layout
+	Desc    string
+	Amount  string //Amount to two decimals
+	Keyword string
+}
+
+// Writes the summary using the -template instead of the usual layout
+func writeTemplate(w io.Writer, name string, res Result, date1 time.Time, date2 time.Time) {
+	data := templateData{
+		File:     name,
+		Start:    date1.Format(dateEntry),
+		End:      date2.Format(dateEntry),
+		Total:    formatAmount(res.Total),
+		Count:    len(res.Transactions),
+		Lines:    res.Lines,
+		Interest: formatAmount(res.Interest),
+		Hash:     res.Hash,
+	}
+	for _, trx := range listedFees(res.Transactions) {
+		data.Fees = append(data.Fees, templateFee{Date: trx.Date, Desc: trx.Desc, Amount: formatAmount(trx.Amount), Keyword: trx.Keyword})
+	}
+
+	var out strings.Builder
+	if err := summaryTemplate.Execute(&out, data); err != nil {
+		fail("Could not fill in the -template:", err)
+		return
+	}
+	text := out.String()
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	fmt.Fprint(w, text)
+}
+
+// Writes only the result, for scripts: the total (as a bare number with -raw) and the count with -count
+// -bare leaves out everything else, even the newline, so the output is exactly the total
+// Warnings go to stderr so they don't get mixed into the result
+func writeQuiet(w io.Writer, res Result) {
+	printWarnings(os.Stderr, res)
+	if *bareFlag {
+		//Currencies are never added together, and there's only room for one number
+		if len(res.ByCurrency) > 1 {
+			fail("-bare can only print one total, but the fees are in more than one currency:", strings.Join(sortedKeys(res.ByCurrency), ", "))
+			return
+		}
+		fmt.Fprint(w, formatAmount(res.Total))
+		return
+	}
+	switch {
+	case !*rawFlag:
+		writeTotal(w, res.Total, res.ByCurrency)
+	case len(res.ByCurrency) > 1:
+		for _, currency := range sortedKeys(res.ByCurrency) {
+			fmt.Fprintln(w, formatAmount(res.ByCurrency[currency]), currency)
+		}
+	default:
+		total := res.Total
+		if isZero(total) {
+			total = 0
+		}
+		fmt.Fprintln(w, formatAmount(total))
+	}
+	if *countFlag {
+		if *rawFlag {
+			fmt.Fprintln(w, len(res.Transactions))
+		} else {
+			fmt.Fprintln(w, "COUNT:", len(res.Transactions))
+		}
+	}
+}
+
+// Writes the counts of transactions that were handled specially
+func writeCounts(w io.Writer, res Result) {
+	if res.Reversals > 0 {
+		fmt.Fprintln(w, "Reversals applied:", res.Reversals)
+	}
+	if *dedupFlag {
+		fmt.Fprintln(w, "Duplicate fees left out:", res.Duplicates, "(only fees are deduplicated, not interest or other totals)")
+	}
+	if res.HeadersSkipped > 0 {
+		fmt.Fprintln(w, "Repeated header rows skipped:", res.HeadersSkipped, "(the file has statements one after another)")
+	}
+	if *verifySubtotalsFlag {
+		fmt.Fprintln(w, "Subtotals checked:", res.SubtotalsChecked, "("+strconv.Itoa(res.SubtotalErrors), "with problems)")
+	}
+	if *debitsOnlyFlag {
+		fmt.Fprintln(w, "Credits left out:", res.CreditsIgnored)
+	}
+	if *weekdaysOnlyFlag {
+		fmt.Fprintln(w, "Weekend transactions skipped:", res.WeekendSkipped)
+	}
+	if res.ReviewAccepted+res.ReviewRejected > 0 {
+		fmt.Fprintln(w, "Reviewed matches:", res.ReviewAccepted, "accepted,", res.ReviewRejected, "rejected")
+	}
+}
+
+// Writes the report totals, the fee total and anything else asked for by flags
+func writeTotals(w io.Writer, res Result) {
+	printReports(w, res.Reports)
+	if *nonFeesFlag {
+		fmt.Fprintln(w, "NON-FEE TOTAL:", formatAmount(res.NonFeeTotal), "("+strconv.Itoa(res.NonFeeCount), "transactions)")
+	}
+	if *avgPerKeywordFlag {
+		writeKeywordAverages(w, res.ByKeyword, res.ByKeywordCount)
+	}
+	if *counterpartyFlag {
+		writeCounterparties(w, res.ByCounterparty)
+	}
+	if *descriptionsFlag {
+		writeDescriptions(w, res.Transactions)
+	}
+	writeTotal(w, res.Total, res.ByCurrency)
+	if res.InterestCount > 0 {
+		fmt.Fprintln(w, "INTEREST:", formatAmount(res.Interest), "("+strconv.Itoa(res.InterestCount), "transactions)")
+		fmt.Fprintln(w, "FEES + INTEREST:", formatAmount(res.Total+res.Interest))
+	}
+	if *principalFlag != 0 {
+		writeAnnualRate(w, res)
+	}
+	if *pctDebitsFlag {
+		writeDebitShare(w, res)
+	}
+	if *openingBalanceFlag != "" {
+		writeBalances(w, res)
+	}
+	if res.Largest.Keyword != "" {
+		fmt.Fprintln(w, "Smallest fee:", formatAmount(res.Smallest.Amount), "on", res.Smallest.Date.Format(dateEntry)+", Largest fee:", formatAmount(res.Largest.Amount), "on", res.Largest.Date.Format(dateEntry))
+	}
+	if *countFlag {
+		fmt.Fprintln(w, "Fees found:", len(res.Transactions))
+	}
+	if *medianFlag {
+		writeAverages(w, res.Transactions)
+	}
+	if *distinctDaysFlag {
+		fmt.Fprintln(w, "Fees occurred on", len(res.ByDay), plural("distinct day", int64(len(res.ByDay))), "in the range.")
+	}
+	if *previewFlag > 0 {
+		writePreview(w, listedFees(res.Transactions), *previewFlag)
+	}
+	if feeSchedule != nil {
+		writeScheduleCheck(w, res.Transactions)
+	}
+	if isZero(res.Total) {
+		if len(res.Transactions) == 0 {
+			fmt.Fprintln(w, "No fees were found in this date range. Check the dates and that this is the right file.")
+		} else {
+			fmt.Fprintln(w, "The fees found cancel each other out.")
+		}
+	}
+	if *peakDayFlag {
+		writePeakDay(w, res.ByDay)
+	}
+	if *byWeekdayFlag {
+		writeWeekdays(w, res.ByWeekday)
+	}
+	if *unusedKeywordsFlag {
+		writeUnusedKeywords(w, res.ByKeyword)
+	}
+	if *suggestFlag {
+		writeSuggestions(w, res.Suggestions)
+	}
+	fmt.Fprintln(w)
+	if *chartFlag {
+		writeChart(w, res.ByMonth, *chartWidthFlag)
+	}
+	if *pieFlag {
+		writePie(w, res.ByKeyword, res.Total, *chartWidthFlag)
+	}
+}
+
+// Writes the first n and last n fees by date, with "..." for the ones in between
+// Fewer than 2n fees are all written, so none is shown twice
+func writePreview(w io.Writer, transactions []Transaction, n int) {
+	if len(transactions) == 0 {
+		return
+	}
+	transactions = append([]Transaction(nil), transactions...)
+	sort.SliceStable(transactions, func(i, j int) bool { return transactions[i].Date.Before(transactions[j].Date) })
+	fmt.Fprintln(w, "First and last fees:")
+	for i, trx := range transactions {
+		if i == n && len(transactions) > 2*n {
+			fmt.Fprintln(w, "  ...", len(transactions)-2*n, "more")
+		}
+		if i < n || i >= len(transactions)-n {
+			fmt.Fprintln(w, "  "+trx.Date.Format(dateEntry), trx.Desc+":", formatAmount(trx.Amount))
+		}
+	}
+}
+
+// Writes the average and median fee, leaving out reversals as Smallest and Largest do
+func writeAverages(w io.Writer, transactions []Transaction) {
+	var amounts []float64
+	total := 0.0
+	for _, trx := range transactions {
+		if !trx.Reversal {
+			amounts = append(amounts, trx.Amount)
+			total += trx.Amount
+		}
+	}
+	if len(amounts) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Average fee:", formatAmount(total/float64(len(amounts)))+", Median fee:", formatAmount(median(amounts)))
+}
+
+// Returns the middle amount, or the average of the two middle ones when there's an even number
+func median(amounts []float64) float64 {
+	sorted := append([]float64(nil), amounts...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// Writes the processing statistics for -stats, with the skipped lines broken down by the kind of problem
+func writeStats(w io.Writer, res Result) {
+	fmt.Fprintln(w, "Statistics:")
+	fmt.Fprintf(w, "  %-22s %d\n", "Rows read:", res.Lines)
+	fmt.Fprintf(w, "  %-22s %d\n", "Rows in range:", res.InRange)
+	fmt.Fprintf(w, "  %-22s %d\n", "Fees matched:", len(res.Transactions))
+	fmt.Fprintf(w, "  %-22s %d\n", "Interest matched:", res.InterestCount)
+	fmt.Fprintf(w, "  %-22s %d\n", "Rows skipped:", res.Skipped)
+	for _, kind := range sortedKeys(res.SkippedBy) {
+		fmt.Fprintf(w, "    %-20s %d\n", kind+":", res.SkippedBy[kind])
+	}
+	fmt.Fprintf(w, "  %-22s %s\n", "Time:", res.Elapsed.Round(time.Microsecond))
+	fmt.Fprintln(w)
+}
+
+// Writes the fee subtotal for each counterparty, with the fees that have none last
+func writeCounterparties(w io.Writer, byCounterparty map[string]Subtotal) {
+	if len(byCounterparty) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Fees by counterparty:")
+	for _, party := range sortedKeys(byCounterparty) {
+		if party != unknownCounterparty {
+			writeCounterparty(w, party, byCounterparty[party])
+		}
+	}
+	if subtotal, ok := byCounterparty[unknownCounterparty]; ok {
+		writeCounterparty(w, unknownCounterparty, subtotal)
+	}
+}
+
+func writeCounterparty(w io.Writer, party string, subtotal Subtotal) {
+	writeCategory(w, party, subtotal.Total, subtotal.Count)
+}
+
+// Writes each distinct fee description with its number of fees and subtotal, the most expensive first
+func writeDescriptions(w io.Writer, fees []Transaction) {
+	if len(fees) == 0 {
+		return
+	}
+	byDesc := make(map[string]Subtotal)
+	for _, fee := range fees {
+		desc := strings.TrimSpace(fee.Desc)
+		byDesc[desc] = byDesc[desc].add(fee.Amount, 1)
+	}
+	descs := sortedKeys(byDesc)
+	sort.SliceStable(descs, func(i, j int) bool {
+		return byDesc[descs[i]].Total > byDesc[descs[j]].Total
+	})
+	fmt.Fprintln(w, "Fees by description:")
+	for _, desc := range descs {
+		writeCategory(w, desc, byDesc[desc].Total, byDesc[desc].Count)
+	}
+}
+
+// Writes each fee word's subtotal, number of fees and average fee
+func writeKeywordAverages(w io.Writer, byKeyword map[string]float64, counts map[string]int) {
+	if len(byKeyword) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Fees by keyword:")
+	for _, keyword := range sortedKeys(byKeyword) {
+		writeCategory(w, keyword, byKeyword[keyword], counts[keyword])
+	}
+}
+
+// Writes one line of a breakdown; with -avg-per-keyword it has the average fee too
+func writeCategory(w io.Writer, name string, total float64, count int) {
+	if *avgPerKeywordFlag && count > 0 {
+		fmt.Fprintln(w, "  "+name+":", formatAmount(total), "("+strconv.Itoa(count), "fees, avg", formatAmount(total/float64(count)), "per transaction)")
+		return
+	}
+	fmt.Fprintln(w, "  "+name+":", formatAmount(total), "("+strconv.Itoa(count), "fees)")
+}
+
+// Writes the interest in range as an effective annual rate on the -principal: interest/principal * 365/days * 100
+// With -interest-word only that word's interest counts
+func writeAnnualRate(w io.Writer, res Result) {
+	interest := res.Interest
+	if *interestWordFlag != "" {
+		interest = res.ByInterest[*interestWordFlag]
+	}
+	if *principalFlag <= 0 || isZero(*principalFlag) {
+		fmt.Fprintln(w, "The -principal must be more than zero to work out an annual rate.")
+		return
+	}
+	if res.Days <= 0 {
+		fmt.Fprintln(w, "The date range has no days to work out an annual rate over.")
+		return
+	}
+	rate := interest / *principalFlag * 365 / float64(res.Days) * 100
+	fmt.Fprintf(w, "Effective annual rate: %s%% (%s interest on %s over %d %s)\n", formatPercent(rate), formatAmount(interest), formatAmount(*principalFlag), res.Days, plural("day", int64(res.Days)))
+}
+
+// Writes what share of all the debits in the range went to fees
+// All debits are the fees, the interest and the other debits together, whatever their description
+func writeDebitShare(w io.Writer, res Result) {
+	debits := res.Total + res.Interest + res.NonFeeTotal
+	if isZero(debits) {
+		fmt.Fprintln(w, "There are no debits in this date range to compare the fees to.")
+		return
+	}
+	pct := res.Total / debits * 100
+	fmt.Fprintf(w, "Fees are %s%% of total debits (%s of %s)\n", formatPercent(pct), formatAmount(res.Total), formatAmount(debits))
+}
+
+// Writes the fee words that matched nothing. A word only counts when it's the first in the list to match,
+// so one that's always beaten by an earlier word shows up here too
+func writeUnusedKeywords(w io.Writer, byKeyword map[string]float64) {
+	var unused []string
+	for _, keyword := range feeList {
+		if _, ok := byKeyword[keyword]; !ok {
+			unused = append(unused, keyword)
+		}
+	}
+	if len(unused) == 0 {
+		fmt.Fprintln(w, "Every fee keyword was used.")
+		return
+	}
+	fmt.Fprintln(w, "Unused keywords:", strings.Join(unused, ", "))
+}
+
+// Writes a table of the fees on each day of the week, starting on Monday
+func writeWeekdays(w io.Writer, byWeekday [7]Subtotal) {
+	fmt.Fprintln(w, "Fees by weekday:")
+	for i := 1; i <= 7; i++ {
+		day := time.Weekday(i % 7)
+		subtotal := byWeekday[day]
+		fmt.Fprintf(w, "  %-10s %4d %12s\n", day.String()+":", subtotal.Count, formatAmount(subtotal.Total))
+	}
+}
+
+// Writes the day with the highest fee total; ties go to the earliest day
+func writePeakDay(w io.Writer, byDay map[string]Subtotal) {
+	peak := ""
+	for _, day := range sortedKeys(byDay) {
+		if peak == "" || byDay[day].Total > byDay[peak].Total {
+			peak = day
+		}
+	}
+	if peak == "" {
+		return
+	}
+	fmt.Fprintln(w, "Highest fee day:", peak, "with", byDay[peak].Count, "fees totaling", formatAmount(byDay[peak].Total))
+}
+
+// Writes the TOTAL line, or one total per currency if the fees are in more than one
+// Amounts in different currencies are never added together
+func writeTotal(w io.Writer, total float64, byCurrency map[string]float64) {
+	if len(byCurrency) > 1 {
+		for _, currency := range sortedKeys(byCurrency) {
+			label := currency
+			if label == "" {
+				label = "no currency"
+			}
+			fmt.Fprintln(w, "TOTAL ("+label+"):", formatAmount(byCurrency[currency]))
+		}
+		fmt.Fprintln(w, "The fees are in more than one currency, so they have not been combined.")
+		return
+	}
+
+	if isZero(total) {
+		total = 0 //Floating point residue shouldn't print as -0.00
+	}
+	fmt.Fprintln(w, "TOTAL:", formatAmount(total))
+	if *wordsFlag {
+		fmt.Fprintln(w, amountToWords(total, wordsLang()))
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+// Month by fee word table for spreadsheets, with -pivot: one row per month, one column per fee word,
+// plus a total for each row and column
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Writes the pivot table as .csv, or tab separated for .tsv
+func writePivot(path string, byMonthKeyword map[string]map[string]float64) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if strings.EqualFold(filepath.Ext(path), ".tsv") {
+		writer.Comma = '\t'
+	}
+
+	//Every fee word that turns up in any month gets a column
+	columns := make(map[string]float64)
+	for _, byKeyword := range byMonthKeyword {
+		for keyword := range byKeyword {
+			columns[keyword] += 0
+		}
+	}
+	keywords := sortedKeys(columns)
+
+	writer.Write(append(append([]string{"Month"}, keywords...), "Total"))
+	var grandTotal float64 = 0
+	for _, month := range sortedKeys(byMonthKeyword) {
+		row := []string{month}
+		var monthTotal float64 = 0
+		for _, keyword := range keywords {
+			amount := byMonthKeyword[month][keyword]
+			row = append(row, machineAmount(amount))
+			monthTotal += amount
+			columns[keyword] += amount
+		}
+		grandTotal += monthTotal
+		writer.Write(append(row, machineAmount(monthTotal)))
+	}
+	totals := []string{"Total"}
+	for _, keyword := range keywords {
+		totals = append(totals, machineAmount(columns[keyword]))
+	}
+	writer.Write(append(totals, machineAmount(grandTotal)))
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,30 @@
+package main
+
+// Finding ragged rows with -warn-ragged. The csv reader accepts any number of fields in a row so that a bank
+// adding a column doesn't break it, but a row with a different count from the header can also mean the file
+// is damaged or a field has an unquoted comma, which shifts the columns after it.
+
+import "fmt"
+
+// Most ragged rows listed one by one; the rest are only counted
+const raggedListMax = 20
+
+// Returns a warning for each row whose field count differs from the header's, numbered the same way as skipped lines
+func raggedRows(header []string, data [][]string) []string {
+	var warnings []string
+	extra := 0
+	for i, row := range data {
+		if len(row) == len(header) {
+			continue
+		}
+		if len(warnings) == raggedListMax {
+			extra++
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("Line %d has %d fields but the header has %d.", i+1, len(row), len(header)))
+	}
+	if extra > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d more lines have a different number of fields from the header.", extra))
+	}
+	return warnings
+}
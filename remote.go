@@ -0,0 +1,74 @@
+package main
+
+// Reading statements straight from a web address: any file argument starting with http:// or https://
+// is downloaded instead of opened, with -basic-auth for servers that ask for a login
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// How long a download may take before giving up, including reading the whole file
+const fetchTimeout = 60 * time.Second
+
+// Checks whether a file argument is a web address rather than a file on disk
+func isURL(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// Returns the extension of a file argument, ignoring any query string on a web address
+func inputExt(name string) string {
+	if isURL(name) {
+		if u, err := url.Parse(name); err == nil {
+			return path.Ext(u.Path)
+		}
+	}
+	return path.Ext(strings.ReplaceAll(name, "\\", "/"))
+}
+
+// Opens a file argument for reading, downloading it if it's a web address
+// With -hash the file's SHA-256 is worked out as it's read, for inputHash
+func openInput(name string) (io.ReadCloser, error) {
+	input, err := openRawInput(name)
+	if err != nil || !*hashFlag {
+		return input, err
+	}
+	return newHashingReader(name, input), nil
+}
+
+func openRawInput(name string) (io.ReadCloser, error) {
+	if !isURL(name) {
+		return os.Open(name)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("The address %s is not valid: %w", name, err)
+	}
+	if *basicAuthFlag != "" {
+		user, pass, ok := strings.Cut(*basicAuthFlag, ":")
+		if !ok {
+			return nil, errors.New("The -basic-auth must be given as user:password.")
+		}
+		req.SetBasicAuth(user, pass)
+	}
+
+	client := http.Client{Timeout: fetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Could not download %s: %w", name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Could not download %s: the server answered %s.", name, resp.Status)
+	}
+	return resp.Body, nil
+}
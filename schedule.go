@@ -0,0 +1,80 @@
+package main
+
+// Checks the fees charged against the bank's published fee schedule with -schedule, to catch overcharges
+// The schedule is a .csv of fee word and expected amount, e.g.
+//
+//	Keyword,Amount
+//	frais,10.00
+//	timbre,5.00
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Expected amount for each fee word, from -schedule; nil when not checking
+var feeSchedule map[string]float64
+
+// Reads the fee schedule. A first row that doesn't have an amount is taken as a header and skipped
+func loadSchedule(path string) (map[string]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := make(map[string]float64)
+	for i, row := range rows {
+		if len(row) < 2 {
+			continue
+		}
+		amount, err := strconv.ParseFloat(strings.TrimSpace(row[1]), 64)
+		if err != nil {
+			if i == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d of the schedule has an invalid amount \"%s\"", i+1, row[1])
+		}
+		schedule[strings.TrimSpace(row[0])] = amount
+	}
+	if len(schedule) == 0 {
+		return nil, errors.New("the schedule has no fees in it")
+	}
+	return schedule, nil
+}
+
+// Compares each fee against its scheduled amount and warns about any that differ, then totals the overcharges
+// Reversals and fee words that aren't in the schedule are left alone.
+func writeScheduleCheck(w io.Writer, transactions []Transaction) {
+	var overcharged float64 = 0
+	mismatches := 0
+	for _, trx := range transactions {
+		expected, ok := feeSchedule[trx.Keyword]
+		if !ok || trx.Reversal || nearlyEqual(trx.Amount, expected) {
+			continue
+		}
+		mismatches += 1
+		fmt.Fprintln(w, "Schedule mismatch:", trx.Date.Format(dateEntry), trx.Desc, "charged", formatAmount(trx.Amount), "but the schedule says", formatAmount(expected))
+		if trx.Amount > expected {
+			overcharged += trx.Amount - expected
+		}
+	}
+	if mismatches == 0 {
+		fmt.Fprintln(w, "All fees match the schedule.")
+		return
+	}
+	fmt.Fprintln(w, "Fees not matching the schedule:", mismatches)
+	fmt.Fprintln(w, "Total overcharged:", formatAmount(overcharged))
+}
@@ -0,0 +1,60 @@
+package main
+
+// The effective settings for a run, printed with -show-config so a saved report says how it was made
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Writes every setting that affects the result: columns, formats, word lists, config and the flags given
+// Quiet runs send it to stderr so that stdout only has the result
+func showSettings(date1 time.Time, date2 time.Time) {
+	var w io.Writer = os.Stdout
+	if *quietFlag {
+		w = os.Stderr
+	}
+
+	fmt.Fprintln(w, "Settings:")
+	fmt.Fprintf(w, "  Columns: date %q, description %q, amount %q\n", *dateColFlag, *descColFlag, *amntColFlag)
+	fmt.Fprintf(w, "  Optional columns: reference %q, currency %q\n", refField, curField)
+	fmt.Fprintln(w, "  Date format in files:", dateFormat, "(otherwise detected from:", strings.Join(dateCandidates[1:], ", ")+")")
+	fmt.Fprintln(w, "  Date format for entry:", dateEntry)
+	fmt.Fprintln(w, "  Delimiter: comma")
+	fmt.Fprintln(w, "  Encoding: UTF-8")
+	fmt.Fprintln(w, "  Date range:", date1.Format(dateEntry), "to", date2.Format(dateEntry))
+	fmt.Fprintln(w, "  Time zone:", location)
+	fmt.Fprintln(w, "  Fee words:", strings.Join(feeList, ", "))
+	fmt.Fprintln(w, "  Reversal words:", strings.Join(reversalList, ", "))
+	fmt.Fprintln(w, "  Interest words:", strings.Join(interestList, ", "))
+	fmt.Fprintln(w, "  Ambiguous words:", strings.Join(ambiguousList, ", "))
+	fmt.Fprintln(w, "  Always fees:", strings.Join(includeList, ", "))
+	for _, profile := range config.Reports {
+		column := profile.Column
+		if column == "" {
+			column = *descColFlag
+		}
+		fmt.Fprintf(w, "  Report %q: %s in %q\n", profile.Name, strings.Join(profile.Keywords, ", "), column)
+	}
+	if feeSchedule != nil {
+		fmt.Fprintln(w, "  Fee schedule:", *scheduleFlag)
+	}
+
+	var flags []string
+	flag.Visit(func(f *flag.Flag) {
+		value := f.Value.String()
+		if f.Name == "basic-auth" {
+			user, _, _ := strings.Cut(value, ":")
+			value = user + ":****" //Reports get shared; the password shouldn't go with them
+		}
+		flags = append(flags, "-"+f.Name+"="+value)
+	})
+	if len(flags) > 0 {
+		fmt.Fprintln(w, "  Flags:", strings.Join(flags, " "))
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,75 @@
+package main
+
+// Saves matched transactions to a SQLite database with -sqlite, for querying across months outside the program
+// The SQLite driver isn't in the standard library, so it is only compiled in when building with
+// "go build -tags sqlite" (see sqlite_driver.go). go.mod doesn't require the driver, so that build needs
+// "go get modernc.org/sqlite" first. Without it -sqlite reports that support is missing before processing anything.
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var errNoSQLite = errors.New("this copy of the program was built without SQLite support (build with -tags sqlite)")
+
+const sqliteTable = `CREATE TABLE IF NOT EXISTS fees (
+	run_id      TEXT NOT NULL,
+	source_file TEXT NOT NULL,
+	date        TEXT NOT NULL,
+	description TEXT NOT NULL,
+	amount      REAL NOT NULL,
+	keyword     TEXT NOT NULL
+)`
+
+// Checks whether the SQLite driver was compiled in
+func sqliteAvailable() bool {
+	for _, driver := range sql.Drivers() {
+		if driver == "sqlite" {
+			return true
+		}
+	}
+	return false
+}
+
+// Inserts every matched transaction in the results into the fees table, creating it if needed
+// All rows from one call share a run id so separate runs can be told apart
+func writeSQLite(path string, results []Result) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown driver") {
+			return errNoSQLite
+		}
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(sqliteTable); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("INSERT INTO fees (run_id, source_file, date, description, amount, keyword) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	runID := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, res := range results {
+		for _, trx := range res.Transactions {
+			_, err := stmt.Exec(runID, filepath.Base(res.File), trx.Date.Format(dateEntry), trx.Desc, trx.Amount, trx.Keyword)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,10 @@
+//go:build sqlite
+
+package main
+
+// Registers the pure-Go SQLite driver used by -sqlite. Build with:
+//
+//	go get modernc.org/sqlite
+//	go build -tags sqlite
+
+import _ "modernc.org/sqlite"
@@ -0,0 +1,69 @@
+package main
+
+// Checking the per-page subtotal rows some exports have, with -verify-subtotals: the detail rows since the
+// previous subtotal (or the start of the file) should add up to the amount on the subtotal row
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Checks whether a description marks a subtotal row rather than a transaction
+func isSubtotalRow(desc string) bool {
+	return *subtotalMarkerFlag != "" && strings.Contains(strings.ToUpper(desc), strings.ToUpper(*subtotalMarkerFlag))
+}
+
+// Adds up each section of rows and compares it with the subtotal row that ends it, over the whole file
+// rather than just the date range, since the sections are pages of the export
+// Returns the number of sections checked and a message for each one that doesn't match
+func checkSubtotals(data [][]string, colDesc int, colAmnt int) (int, []string) {
+	sections := 0
+	var mismatches []string
+	var sum float64 = 0
+	start := 1
+	unreadable := 0
+	for i, row := range data {
+		line := i + 1
+		if colDesc >= len(row) || colAmnt >= len(row) {
+			continue
+		}
+		cell := strings.TrimSpace(row[colAmnt])
+		if !isSubtotalRow(row[colDesc]) {
+			if cell == "" {
+				continue
+			}
+			amount, _, err := parseAmount(cell)
+			if err != nil {
+				unreadable += 1
+				continue
+			}
+			sum += amount
+			continue
+		}
+
+		sections += 1
+		stated, _, err := parseAmount(cell)
+		switch {
+		case err != nil:
+			mismatches = append(mismatches, fmt.Sprintf("The subtotal on line %d (%q) is not a number.", line, cell))
+		case !nearlyEqual(sum, stated):
+			mismatches = append(mismatches, fmt.Sprintf("The rows on %s add up to %s but the subtotal on line %d is %s.", lineSpan(start, line-1), formatAmount(sum), line, formatAmount(stated)))
+		}
+		if unreadable > 0 {
+			mismatches = append(mismatches, fmt.Sprintf("The rows on %s have %d amounts that could not be read, so their subtotal could not be fully checked.", lineSpan(start, line-1), unreadable))
+		}
+		sum = 0
+		start = line + 1
+		unreadable = 0
+	}
+	return sections, mismatches
+}
+
+// Describes a run of lines, e.g. "lines 4-9", or "line 4" when it's just one
+func lineSpan(first int, last int) string {
+	if first >= last {
+		return "line " + strconv.Itoa(first)
+	}
+	return "lines " + strconv.Itoa(first) + "-" + strconv.Itoa(last)
+}
@@ -0,0 +1,97 @@
+package main
+
+// Finding fee wordings the word lists miss, with -suggest
+// A description that matched no fee or interest word is suggested when either:
+//   - one of its words is within -suggest-distance letter changes of a fee word, ignoring case
+//     (so "FRAIS", "fras" and "frai" are all caught for "frais"); words shorter than 4 letters are ignored
+//   - it has an amount written in it, like "25.00" or "25,00", of at most -suggest-max
+//     (banks often put the fee in the text, e.g. "TENUE DE COMPTE 25.00 HTG")
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A description that looks like a fee, how many times it came up, and why it was picked
+type Suggestion struct {
+	Count  int
+	Reason string
+}
+
+// Amounts written into descriptions, with a point or a comma before the cents
+var descAmount = regexp.MustCompile(`\b\d{1,6}[.,]\d{2}\b`)
+
+// Returns why the description looks like a fee, or "" if it doesn't
+func suggestReason(desc string) string {
+	words := strings.FieldsFunc(strings.ToLower(desc), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		if len([]rune(word)) < 4 {
+			continue
+		}
+		for _, keyword := range feeList {
+			keyword = strings.ToLower(strings.Trim(keyword, ". "))
+			if editDistance(word, keyword) <= *suggestDistanceFlag {
+				return "looks like \"" + keyword + "\""
+			}
+		}
+	}
+
+	for _, match := range descAmount.FindAllString(desc, -1) {
+		amount, err := strconv.ParseFloat(strings.Replace(match, ",", ".", 1), 64)
+		if err == nil && amount > 0 && amount <= *suggestMaxFlag {
+			return "small amount " + match + " in the text"
+		}
+	}
+	return ""
+}
+
+// Number of single letter insertions, deletions or substitutions to turn a into b
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// Writes the suggested descriptions, most frequent first
+func writeSuggestions(w io.Writer, suggestions map[string]Suggestion) {
+	if len(suggestions) == 0 {
+		fmt.Fprintln(w, "No unmatched descriptions look like fees.")
+		return
+	}
+	descs := sortedKeys(suggestions)
+	sort.SliceStable(descs, func(i, j int) bool {
+		return suggestions[descs[i]].Count > suggestions[descs[j]].Count
+	})
+	fmt.Fprintln(w, "Unmatched descriptions that look like fees:")
+	for _, desc := range descs {
+		fmt.Fprintf(w, "  %d× %s (%s)\n", suggestions[desc].Count, desc, suggestions[desc].Reason)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+// Keyword tuning session with -interactive: the file is read once, then fee words can be added and removed
+// and the total is recalculated straight away from the rows already in memory
+//
+//	add WORD      start counting descriptions containing WORD as fees
+//	remove WORD   stop counting WORD
+//	list          show the fee words and what each one matched
+//	save          write the fee words to feewords.txt next to the program, for future runs
+//	quit          stop
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Runs the tuning session on one file's rows over the date range
+func tuneKeywords(header []string, data [][]string, date1 time.Time, date2 time.Time) {
+	res, ok := tuningPass(header, data, date1, date2)
+	if !ok {
+		return
+	}
+	fmt.Println("Commands: add WORD, remove WORD, list, save, quit")
+
+	input := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("> ")
+		if !input.Scan() {
+			return
+		}
+		command, word, _ := strings.Cut(strings.TrimSpace(input.Text()), " ")
+		word = strings.TrimSpace(word)
+		switch strings.ToLower(command) {
+		case "":
+		case "add":
+			if word == "" {
+				fmt.Println("Give the word to add, e.g. add frais")
+				continue
+			}
+			if indexOf(feeList, word) >= 0 {
+				fmt.Println(word, "is already a fee word.")
+				continue
+			}
+			feeList = append(feeList, word)
+			res, ok = retune(res, header, data, date1, date2)
+		case "remove":
+			i := indexOf(feeList, word)
+			if i < 0 {
+				fmt.Println(word, "is not a fee word.")
+				continue
+			}
+			feeList = append(feeList[:i], feeList[i+1:]...)
+			res, ok = retune(res, header, data, date1, date2)
+		case "list":
+			listKeywords(res)
+		case "save":
+			if err := saveWordList(feeFile, feeList); err != nil {
+				fmt.Println("Could not save the fee words:", err)
+			} else {
+				fmt.Println("Saved", len(feeList), "fee words to", programFile(feeFile))
+			}
+		case "quit", "exit", "q":
+			return
+		default:
+			fmt.Println("Unknown command. Commands: add WORD, remove WORD, list, save, quit")
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// Recalculates with the current fee words and prints the total and the number of fees
+func tuningPass(header []string, data [][]string, date1 time.Time, date2 time.Time) (Result, bool) {
+	res, err := calculate(header, data, date1, date2, false)
+	if err != nil {
+		fmt.Println(err)
+		return res, false
+	}
+	fmt.Println("TOTAL:", formatAmount(res.Total), "("+strconv.Itoa(len(res.Transactions)), "fees)")
+	return res, true
+}
+
+// Recalculates after a change to the fee words and shows which fees the change added or took away
+func retune(previous Result, header []string, data [][]string, date1 time.Time, date2 time.Time) (Result, bool) {
+	res, ok := tuningPass(header, data, date1, date2)
+	if ok {
+		writeMatchDiff(os.Stdout, previous.Transactions, res.Transactions)
+	}
+	return res, ok
+}
+
+// Prints each fee word with the total and number of fees it matched
+func listKeywords(res Result) {
+	counts := make(map[string]int)
+	for _, trx := range res.Transactions {
+		counts[trx.Keyword] += 1
+	}
+	for _, keyword := range feeList {
+		fmt.Println(" ", keyword+":", formatAmount(res.ByKeyword[keyword]), "("+strconv.Itoa(counts[keyword]), "fees)")
+	}
+}
+
+// Returns the position of word in words, or -1
+func indexOf(words []string, word string) int {
+	for i, w := range words {
+		if w == word {
+			return i
+		}
+	}
+	return -1
+}
+
+// Writes a word list file next to the program, one word per line, in the format loadWordList reads
+func saveWordList(name string, words []string) error {
+	return os.WriteFile(programFile(name), []byte(strings.Join(words, "\n")+"\n"), 0644)
+}
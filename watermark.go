@@ -0,0 +1,116 @@
+package main
+
+// "Since last run" mode with -since-last: the last date processed for each file is remembered in a small
+// state file next to the program, and the next run starts from the day after it
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Name of the state file, in the same folder as the program
+const watermarkFile = "watermarks.json"
+
+// Key for a file in the state file: the full path, so files with the same name in different folders are kept apart
+func watermarkKey(currFile string) string {
+	if isURL(currFile) {
+		return currFile
+	}
+	if abs, err := filepath.Abs(currFile); err == nil {
+		return abs
+	}
+	return currFile
+}
+
+// Reads the last date processed for every file; a missing state file just means nothing has been processed yet
+func loadWatermarks() (map[string]string, error) {
+	watermarks := make(map[string]string)
+	content, err := os.ReadFile(programFile(watermarkFile))
+	if os.IsNotExist(err) {
+		return watermarks, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(content, &watermarks)
+	return watermarks, err
+}
+
+// Records date as the last one processed for the file
+func saveWatermark(currFile string, date time.Time) error {
+	watermarks, err := loadWatermarks()
+	if err != nil {
+		return err
+	}
+	watermarks[watermarkKey(currFile)] = date.Format(dateEntry)
+	content, err := json.MarshalIndent(watermarks, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(programFile(watermarkFile), content, 0644)
+}
+
+// Works out the range for -since-last: from the day after the file's watermark, or its first date if it has none,
+// to its last date, but no later than today
+// The range is empty (the end before the beginning) when there's nothing new
+func sinceLastRange(currFile string, header []string, data [][]string) (time.Time, time.Time, error) {
+	first, last, err := fileDateSpan(header, data)
+	if err != nil {
+		return first, last, err
+	}
+	today, _ := time.ParseInLocation(dateEntry, time.Now().In(location).Format(dateEntry), location)
+	if last.After(today) {
+		last = today
+	}
+
+	watermarks, err := loadWatermarks()
+	if err != nil {
+		return first, last, errors.New("Could not read " + watermarkFile + ": " + err.Error())
+	}
+	if mark, ok := watermarks[watermarkKey(currFile)]; ok {
+		date, err := time.ParseInLocation(dateEntry, mark, location)
+		if err != nil {
+			return first, last, errors.New("The date saved for this file in " + watermarkFile + " is not valid: " + mark)
+		}
+		first = date.AddDate(0, 0, 1)
+	}
+	return first, last, nil
+}
+
+// Returns the earliest and latest dates among the rows that get processed
+func fileDateSpan(header []string, data [][]string) (time.Time, time.Time, error) {
+	var first, last time.Time
+	colDate := getindex(header, *dateColFlag)
+	if colDate < 0 {
+		return first, last, errors.New("The column \"" + *dateColFlag + "\" was not found in the file.")
+	}
+	if len(data) < 2 {
+		return first, last, errors.New("There are no transactions in the file.")
+	}
+	layout, err := fileDateFormat(data[1:], colDate)
+	if err != nil {
+		return first, last, err
+	}
+	for _, row := range data[1:] {
+		if colDate >= len(row) {
+			continue
+		}
+		date, err := parseDate(layout, row[colDate])
+		if err != nil {
+			continue
+		}
+		date = date.AddDate(0, 0, *dateShiftFlag)
+		if first.IsZero() || date.Before(first) {
+			first = date
+		}
+		if date.After(last) {
+			last = date
+		}
+	}
+	if first.IsZero() {
+		return first, last, errors.New("There are no dates in the file.")
+	}
+	return first, last, nil
+}
@@ -0,0 +1,193 @@
+package main
+
+// Spells out amounts in words for printed vouchers, e.g. 1234.56 is
+// "mille deux cent trente-quatre gourdes et cinquante-six centimes" in French
+// or "one thousand two hundred thirty-four gourdes and fifty-six centimes" in English
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// The largest whole number spelled out; anything bigger is written in digits
+const maxWords = 999999999999
+
+var frOnes = []string{"zéro", "un", "deux", "trois", "quatre", "cinq", "six", "sept", "huit", "neuf", "dix",
+	"onze", "douze", "treize", "quatorze", "quinze", "seize", "dix-sept", "dix-huit", "dix-neuf"}
+var frTens = []string{"", "", "vingt", "trente", "quarante", "cinquante", "soixante", "soixante", "quatre-vingt", "quatre-vingt"}
+
+var enOnes = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten",
+	"eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+var enTens = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+
+// Converts an amount to words in the given locale ("fr" or "en"; anything else is treated as "fr")
+// The whole part is in gourdes and the decimal part, rounded to two places, is in centimes
+func amountToWords(amount float64, locale string) string {
+	cents := int64(math.Round(math.Abs(amount) * 100))
+	whole := cents / 100
+	cents = cents % 100
+
+	var words []string
+	if amount < 0 && (whole != 0 || cents != 0) {
+		words = append(words, pick(locale, "moins", "minus"))
+	}
+	number := numberToWords(whole, locale)
+	if locale != "en" {
+		//Gourde is feminine, so a number ending in "un" agrees with it ("une gourde", "vingt et une gourdes"),
+		//and round millions take "de" ("un million de gourdes")
+		if strings.HasSuffix(number, "un") {
+			number += "e"
+		}
+		if whole >= 1000000 && whole <= maxWords && whole%1000000 == 0 {
+			number += " de"
+		}
+	}
+	words = append(words, number, plural("gourde", whole))
+	if cents != 0 {
+		words = append(words, pick(locale, "et", "and"), numberToWords(cents, locale), plural("centime", cents))
+	}
+	return strings.Join(words, " ")
+}
+
+// Converts a whole number to words in the given locale, or to digits above maxWords
+func numberToWords(n int64, locale string) string {
+	if n > maxWords {
+		return strconv.FormatInt(n, 10)
+	}
+	if locale == "en" {
+		return enNumber(n)
+	}
+	return frNumber(n)
+}
+
+func pick(locale string, fr string, en string) string {
+	if locale == "en" {
+		return en
+	}
+	return fr
+}
+
+func plural(word string, n int64) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// French numbers, following the traditional spelling: "vingt et un", "soixante-dix", "quatre-vingts", "deux cents"
+func frNumber(n int64) string {
+	if n == 0 {
+		return frOnes[0]
+	}
+
+	var parts []string
+	if milliards := n / 1000000000; milliards > 0 {
+		parts = append(parts, frBelowThousand(milliards, true), plural("milliard", milliards))
+		n = n % 1000000000
+	}
+	if millions := n / 1000000; millions > 0 {
+		parts = append(parts, frBelowThousand(millions, true), plural("million", millions))
+		n = n % 1000000
+	}
+	if thousands := n / 1000; thousands > 0 {
+		//"mille" on its own, never "un mille"; and "cent"/"vingt" don't take an s in front of it
+		if thousands > 1 {
+			parts = append(parts, frBelowThousand(thousands, false))
+		}
+		parts = append(parts, "mille")
+		n = n % 1000
+	}
+	if n > 0 {
+		parts = append(parts, frBelowThousand(n, true))
+	}
+	return strings.Join(parts, " ")
+}
+
+// French for 1-999. final is false when the number multiplies "mille", which stops "cents" and "vingts" being plural.
+func frBelowThousand(n int64, final bool) string {
+	var parts []string
+	hundreds := n / 100
+	rest := n % 100
+	if hundreds > 0 {
+		word := "cent"
+		if hundreds > 1 {
+			word = frOnes[hundreds] + " cent"
+			if rest == 0 && final {
+				word += "s"
+			}
+		}
+		parts = append(parts, word)
+	}
+	if rest > 0 {
+		parts = append(parts, frBelowHundred(rest, final))
+	}
+	return strings.Join(parts, " ")
+}
+
+// French for 1-99
+func frBelowHundred(n int64, final bool) string {
+	if n < 20 {
+		return frOnes[n]
+	}
+	tens := n / 10
+	ones := n % 10
+	//70s and 90s count on from 60 and 80, e.g. 72 is "soixante-douze"
+	if tens == 7 || tens == 9 {
+		ones += 10
+	}
+	switch {
+	case ones == 0 && tens == 8 && final:
+		return "quatre-vingts"
+	case ones == 0:
+		return frTens[tens]
+	case (ones == 1 || ones == 11) && tens != 8 && tens != 9:
+		return frTens[tens] + " et " + frOnes[ones]
+	default:
+		return frTens[tens] + "-" + frOnes[ones]
+	}
+}
+
+// English numbers, e.g. "one thousand two hundred thirty-four"
+func enNumber(n int64) string {
+	if n == 0 {
+		return enOnes[0]
+	}
+
+	var parts []string
+	if billions := n / 1000000000; billions > 0 {
+		parts = append(parts, enBelowThousand(billions), "billion")
+		n = n % 1000000000
+	}
+	if millions := n / 1000000; millions > 0 {
+		parts = append(parts, enBelowThousand(millions), "million")
+		n = n % 1000000
+	}
+	if thousands := n / 1000; thousands > 0 {
+		parts = append(parts, enBelowThousand(thousands), "thousand")
+		n = n % 1000
+	}
+	if n > 0 {
+		parts = append(parts, enBelowThousand(n))
+	}
+	return strings.Join(parts, " ")
+}
+
+// English for 1-999
+func enBelowThousand(n int64) string {
+	var parts []string
+	if hundreds := n / 100; hundreds > 0 {
+		parts = append(parts, enOnes[hundreds], "hundred")
+	}
+	rest := n % 100
+	switch {
+	case rest == 0:
+	case rest < 20:
+		parts = append(parts, enOnes[rest])
+	case rest%10 == 0:
+		parts = append(parts, enTens[rest/10])
+	default:
+		parts = append(parts, enTens[rest/10]+"-"+enOnes[rest%10])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestAmountToWords(t *testing.T) {
+	tests := []struct {
+		amount float64
+		fr     string
+		en     string
+	}{
+		{0, "zéro gourdes", "zero gourdes"},
+		{1, "une gourde", "one gourde"},
+		{16, "seize gourdes", "sixteen gourdes"},
+		{17, "dix-sept gourdes", "seventeen gourdes"},
+		{21, "vingt et une gourdes", "twenty-one gourdes"},
+		{71, "soixante et onze gourdes", "seventy-one gourdes"},
+		{80, "quatre-vingts gourdes", "eighty gourdes"},
+		{81, "quatre-vingt-une gourdes", "eighty-one gourdes"},
+		{91, "quatre-vingt-onze gourdes", "ninety-one gourdes"},
+		{100, "cent gourdes", "one hundred gourdes"},
+		{200, "deux cents gourdes", "two hundred gourdes"},
+		{280, "deux cent quatre-vingts gourdes", "two hundred eighty gourdes"},
+		{1000, "mille gourdes", "one thousand gourdes"},
+		{2000, "deux mille gourdes", "two thousand gourdes"},
+		{80000, "quatre-vingt mille gourdes", "eighty thousand gourdes"},
+		{1234.56, "mille deux cent trente-quatre gourdes et cinquante-six centimes", "one thousand two hundred thirty-four gourdes and fifty-six centimes"},
+		{0.21, "zéro gourdes et vingt et un centimes", "zero gourdes and twenty-one centimes"},
+		{1000000, "un million de gourdes", "one million gourdes"},
+		{-5, "moins cinq gourdes", "minus five gourdes"},
+		{999999999, "neuf cent quatre-vingt-dix-neuf millions neuf cent quatre-vingt-dix-neuf mille neuf cent quatre-vingt-dix-neuf gourdes",
+			"nine hundred ninety-nine million nine hundred ninety-nine thousand nine hundred ninety-nine gourdes"},
+		{1000000000, "un milliard de gourdes", "one billion gourdes"},
+		{2500000000, "deux milliards cinq cents millions de gourdes", "two billion five hundred million gourdes"},
+		{999999999999, "neuf cent quatre-vingt-dix-neuf milliards neuf cent quatre-vingt-dix-neuf millions neuf cent quatre-vingt-dix-neuf mille neuf cent quatre-vingt-dix-neuf gourdes",
+			"nine hundred ninety-nine billion nine hundred ninety-nine million nine hundred ninety-nine thousand nine hundred ninety-nine gourdes"},
+		{1000000000000, "1000000000000 gourdes", "1000000000000 gourdes"},
+	}
+	for _, test := range tests {
+		if got := amountToWords(test.amount, "fr"); got != test.fr {
+			t.Errorf("amountToWords(%v, fr) = %q; want %q", test.amount, got, test.fr)
+		}
+		if got := amountToWords(test.amount, "en"); got != test.en {
+			t.Errorf("amountToWords(%v, en) = %q; want %q", test.amount, got, test.en)
+		}
+	}
+}
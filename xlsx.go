@@ -0,0 +1,227 @@
+package main
+
+// Reads Excel .xlsx exports directly, so they don't need re-saving as .csv (which can lose accents or change delimiters)
+// An .xlsx file is a zip of XML files, so this only needs the standard library:
+// the workbook lists the sheets, the sheets hold the cells, and text cells point into a shared strings table.
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRels struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// Text that may be split into formatted runs
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	text := t.T
+	for _, run := range t.Runs {
+		text += run.T
+	}
+	return text
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Style  int      `xml:"s,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// Reads every row of a sheet into the same shape the .csv reader produces
+// sheet is the sheet's name or its number counting from 1; "" reads the first sheet.
+// Cells formatted as dates are returned as yyyy-mm-dd since Excel stores them as numbers.
+func readXLSX(filename string, sheet string) ([][]string, error) {
+	//The zip needs random access, so the whole file is read in first; this also lets it come from a web address
+	input, err := openInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(input)
+	input.Close()
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, errors.New("The file does not appear to be an .xlsx file.")
+	}
+
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var workbook xlsxWorkbook
+	if err := readXML(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels xlsxRels
+	if err := readXML(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	//Shared strings and styles are both optional
+	var shared xlsxSharedStrings
+	readXML(files, "xl/sharedStrings.xml", &shared)
+	var styles xlsxStyles
+	readXML(files, "xl/styles.xml", &styles)
+
+	//Find the sheet that was asked for
+	rid := ""
+	for i, s := range workbook.Sheets {
+		if sheet == "" || s.Name == sheet || strconv.Itoa(i+1) == sheet {
+			rid = s.RID
+			break
+		}
+	}
+	if rid == "" {
+		return nil, errors.New("The sheet \"" + sheet + "\" was not found in the workbook.")
+	}
+	target := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == rid {
+			target = rel.Target
+		}
+	}
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+
+	var ws xlsxSheet
+	if err := readXML(files, target, &ws); err != nil {
+		return nil, err
+	}
+
+	dateStyles := xlsxDateStyles(styles)
+	var rows [][]string
+	for _, r := range ws.Rows {
+		var row []string
+		for i, c := range r.Cells {
+			//Empty cells are left out of the file, so the reference says which column this is
+			col := i
+			if c.Ref != "" {
+				col = xlsxColumn(c.Ref)
+			}
+			for len(row) < col {
+				row = append(row, "")
+			}
+
+			value := c.Value
+			switch c.Type {
+			case "s":
+				index, err := strconv.Atoi(c.Value)
+				if err == nil && index < len(shared.Items) {
+					value = shared.Items[index].String()
+				}
+			case "inlineStr":
+				value = c.Inline.String()
+			case "", "n":
+				if c.Style < len(dateStyles) && dateStyles[c.Style] && value != "" {
+					if serial, err := strconv.ParseFloat(value, 64); err == nil {
+						value = xlsxDate(serial).Format(dateEntry)
+					}
+				}
+			}
+			row = append(row, value)
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// Decodes one of the XML files in the zip
+func readXML(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return errors.New("The workbook is missing " + name + ".")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// Turns a cell reference like "C7" into a column index counting from 0
+func xlsxColumn(ref string) int {
+	col := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A') + 1
+	}
+	return col - 1
+}
+
+// Works out which cell styles display a date, from the built-in date formats and any custom format with days or years in it
+func xlsxDateStyles(styles xlsxStyles) []bool {
+	custom := make(map[int]bool)
+	for _, f := range styles.NumFmts {
+		code := strings.ToLower(f.Code)
+		custom[f.ID] = strings.ContainsAny(code, "dy") && !strings.Contains(code, "[h]")
+	}
+
+	dates := make([]bool, len(styles.CellXfs))
+	for i, xf := range styles.CellXfs {
+		id := xf.NumFmtID
+		dates[i] = (id >= 14 && id <= 22) || (id >= 45 && id <= 47) || custom[id]
+	}
+	return dates
+}
+
+// Converts an Excel date serial (days since 30 Dec 1899) into a date
+func xlsxDate(serial float64) time.Time {
+	days := math.Floor(serial)
+	return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(days))
+}